@@ -0,0 +1,265 @@
+package yall
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy defines what an [AsyncSink] does with a record when its queue is full.
+type OverflowPolicy int
+
+const (
+	OverflowBlock      = OverflowPolicy(iota) // Wait until there is space in the queue.
+	OverflowDropNewest                        // Drop the incoming record.
+	OverflowDropOldest                        // Drop the oldest queued record to make space.
+	OverflowDropBelow                         // Drop the incoming record if its level is below DropLevel, otherwise wait.
+)
+
+// AsyncSinkOptions configures an [AsyncSink].
+type AsyncSinkOptions struct {
+	// Size is the maximum number of queued records. The default is 1024.
+	Size int
+
+	// Overflow is the policy applied when the queue is full.
+	Overflow OverflowPolicy
+
+	// DropLevel is the level below which records are dropped with [OverflowDropBelow].
+	// The default is [slog.LevelInfo].
+	DropLevel slog.Leveler
+
+	// OnError is called on the background goroutine with errors returned by the
+	// wrapped sink. Errors are discarded if OnError is nil.
+	OnError func(error)
+}
+
+var errAsyncSinkClosed = errors.New("yall: async sink is closed")
+
+var (
+	_ Sink    = (*AsyncSink)(nil)
+	_ Flusher = (*AsyncSink)(nil)
+	_ Closer  = (*AsyncSink)(nil)
+)
+
+// AsyncSink is a Sink that queues records and delivers them to another sink
+// on a background goroutine, so that slow sinks don't stall the logging goroutines.
+//
+// Records are cloned with [slog.Record.Clone] before queueing. The context passed
+// to the wrapped sink is detached from the cancellation of the original context.
+//
+// Create instances with [NewAsyncSink]. Call Close to stop the background goroutine.
+type AsyncSink struct {
+	sink    Sink
+	opts    AsyncSinkOptions
+	dropped atomic.Uint64
+
+	lock     sync.Mutex
+	queue    []asyncEntry // ring buffer
+	head     int
+	count    int
+	accepted uint64 // records accepted or dropped so far
+	finished uint64 // records delivered or dropped so far
+	closed   bool
+	space    chan struct{} // closed when space becomes available
+	progress chan struct{} // closed when finished changes
+	wake     chan struct{}
+	stopped  chan struct{}
+}
+
+type asyncEntry struct {
+	ctx    context.Context
+	record slog.Record
+}
+
+// NewAsyncSink creates an AsyncSink delivering records to sink and starts its
+// background goroutine. If opts is nil, the default options are used.
+func NewAsyncSink(sink Sink, opts *AsyncSinkOptions) *AsyncSink {
+	s := &AsyncSink{
+		sink:    sink,
+		wake:    make(chan struct{}, 1),
+		stopped: make(chan struct{}),
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.Size <= 0 {
+		s.opts.Size = 1024
+	}
+	if s.opts.DropLevel == nil {
+		s.opts.DropLevel = slog.LevelInfo
+	}
+	s.queue = make([]asyncEntry, s.opts.Size)
+	go s.run()
+	return s
+}
+
+// Dropped returns the number of records dropped due to queue overflow.
+func (s *AsyncSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *AsyncSink) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.sink.Enabled(ctx, level)
+}
+
+// Handle queues the record for delivery. Depending on the overflow policy, Handle may
+// wait for space in the queue, in which case it returns ctx.Err() if ctx is done first.
+// Dropping a record is not an error.
+func (s *AsyncSink) Handle(ctx context.Context, record slog.Record) error {
+	e := asyncEntry{ctx: context.WithoutCancel(ctx), record: record.Clone()}
+
+	s.lock.Lock()
+	for {
+		if s.closed {
+			s.lock.Unlock()
+			return errAsyncSinkClosed
+		}
+		if s.count < len(s.queue) {
+			break
+		}
+
+		switch s.opts.Overflow {
+		case OverflowDropNewest:
+			s.dropLocked()
+			s.lock.Unlock()
+			return nil
+		case OverflowDropOldest:
+			s.queue[s.head] = asyncEntry{}
+			s.head = (s.head + 1) % len(s.queue)
+			s.count--
+			// The evicted record was already accepted, so it only finishes here.
+			s.finished++
+			s.dropped.Add(1)
+			notifyChan(&s.progress)
+			continue
+		case OverflowDropBelow:
+			if record.Level < s.opts.DropLevel.Level() {
+				s.dropLocked()
+				s.lock.Unlock()
+				return nil
+			}
+		}
+
+		space := broadcastChan(&s.space)
+		s.lock.Unlock()
+		select {
+		case <-space:
+		case <-s.stopped:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		s.lock.Lock()
+	}
+
+	s.queue[(s.head+s.count)%len(s.queue)] = e
+	s.count++
+	s.accepted++
+	s.lock.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Flush waits until all records queued before the call are delivered to the wrapped sink,
+// and then flushes the wrapped sink. It returns ctx.Err() if ctx is done first.
+func (s *AsyncSink) Flush(ctx context.Context) error {
+	s.lock.Lock()
+	target := s.accepted
+	for s.finished < target {
+		progress := broadcastChan(&s.progress)
+		s.lock.Unlock()
+		select {
+		case <-progress:
+		case <-s.stopped:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		s.lock.Lock()
+	}
+	s.lock.Unlock()
+	return Flush(ctx, s.sink)
+}
+
+// Close stops accepting records, waits until the queued records are delivered
+// and the background goroutine exits, and then closes the wrapped sink.
+// It returns ctx.Err() if ctx is done first, in which case the background goroutine
+// keeps delivering the remaining records and the wrapped sink is not closed.
+func (s *AsyncSink) Close(ctx context.Context) error {
+	s.lock.Lock()
+	s.closed = true
+	s.lock.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	select {
+	case <-s.stopped:
+		return Close(ctx, s.sink)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *AsyncSink) dropLocked() {
+	s.accepted++
+	s.finished++
+	s.dropped.Add(1)
+	notifyChan(&s.progress)
+}
+
+func (s *AsyncSink) run() {
+	defer close(s.stopped)
+	for {
+		s.lock.Lock()
+		if s.count == 0 {
+			closed := s.closed
+			s.lock.Unlock()
+			if closed {
+				return
+			}
+			<-s.wake
+			continue
+		}
+		e := s.queue[s.head]
+		s.queue[s.head] = asyncEntry{}
+		s.head = (s.head + 1) % len(s.queue)
+		s.count--
+		notifyChan(&s.space)
+		s.lock.Unlock()
+
+		if s.sink.Enabled(e.ctx, e.record.Level) {
+			if err := s.sink.Handle(e.ctx, e.record); err != nil && s.opts.OnError != nil {
+				s.opts.OnError(err)
+			}
+		}
+
+		s.lock.Lock()
+		s.finished++
+		notifyChan(&s.progress)
+		s.lock.Unlock()
+	}
+}
+
+// broadcastChan returns a channel which is closed by the next notifyChan on c.
+// Must be called with the lock protecting c held.
+func broadcastChan(c *chan struct{}) chan struct{} {
+	if *c == nil {
+		*c = make(chan struct{})
+	}
+	return *c
+}
+
+// notifyChan wakes up all waiters on c. Must be called with the lock protecting c held.
+func notifyChan(c *chan struct{}) {
+	if *c != nil {
+		close(*c)
+		*c = nil
+	}
+}
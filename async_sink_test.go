@@ -0,0 +1,209 @@
+package yall_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"github.com/snake-scaly/yall"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAsyncSink_Handle(t *testing.T) {
+	s := &gatedSink{}
+	a := yall.NewAsyncSink(s, nil)
+
+	for _, m := range []string{"a", "b", "c"} {
+		assert.Nil(t, a.Handle(someCtx, msgRec(someTime, m)))
+	}
+	assert.Nil(t, a.Flush(someCtx))
+	assert.Equal(t, []string{"a", "b", "c"}, s.messages())
+	assert.Nil(t, a.Close(someCtx))
+	assert.NotNil(t, a.Handle(someCtx, msgRec(someTime, "d")))
+}
+
+func TestAsyncSink_Clone(t *testing.T) {
+	s := &gatedSink{}
+	a := yall.NewAsyncSink(s, nil)
+
+	r := rec("a", 1, "b", 2, "c", 3, "d", 4, "e", 5)
+	r1 := r
+	r1.Add("f", 6)
+	assert.Nil(t, a.Handle(someCtx, r1))
+	r2 := r
+	r2.Add("g", 7)
+	assert.Nil(t, a.Close(someCtx))
+
+	assert.Equal(t, " a=1 b=2 c=3 d=4 e=5 f=6", formatToString(yall.TextAttrs{}, nil, s.records[0]))
+}
+
+func TestAsyncSink_ContextDetached(t *testing.T) {
+	s := &gatedSink{gate: make(chan struct{})}
+	a := yall.NewAsyncSink(s, nil)
+
+	ctx, cancel := context.WithCancel(someCtx)
+	assert.Nil(t, a.Handle(ctx, msgRec(someTime, "a")))
+	cancel()
+	close(s.gate)
+	assert.Nil(t, a.Close(someCtx))
+
+	assert.Nil(t, s.ctxErrs[0])
+}
+
+func TestAsyncSink_Overflow(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    yall.AsyncSinkOptions
+		levels  []slog.Level
+		want    []string
+		dropped uint64
+	}{
+		{
+			name:    "DropNewest",
+			opts:    yall.AsyncSinkOptions{Size: 2, Overflow: yall.OverflowDropNewest},
+			levels:  []slog.Level{slog.LevelInfo, slog.LevelInfo, slog.LevelInfo, slog.LevelInfo},
+			want:    []string{"0", "1", "2"},
+			dropped: 1,
+		},
+		{
+			name:    "DropOldest",
+			opts:    yall.AsyncSinkOptions{Size: 2, Overflow: yall.OverflowDropOldest},
+			levels:  []slog.Level{slog.LevelInfo, slog.LevelInfo, slog.LevelInfo, slog.LevelInfo},
+			want:    []string{"0", "2", "3"},
+			dropped: 1,
+		},
+		{
+			name:    "DropBelow",
+			opts:    yall.AsyncSinkOptions{Size: 2, Overflow: yall.OverflowDropBelow, DropLevel: slog.LevelWarn},
+			levels:  []slog.Level{slog.LevelInfo, slog.LevelInfo, slog.LevelInfo, slog.LevelDebug},
+			want:    []string{"0", "1", "2"},
+			dropped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &gatedSink{gate: make(chan struct{}), started: make(chan struct{}, 10)}
+			a := yall.NewAsyncSink(s, &tt.opts)
+
+			for i, l := range tt.levels {
+				r := slog.NewRecord(someTime, l, string(rune('0'+i)), 0)
+				assert.Nil(t, a.Handle(someCtx, r))
+				if i == 0 {
+					// wait until the first record is taken from the queue
+					<-s.started
+				}
+			}
+			close(s.gate)
+			ctx, cancel := context.WithTimeout(someCtx, 5*time.Second)
+			defer cancel()
+			assert.Nil(t, a.Flush(ctx))
+			assert.Nil(t, a.Close(someCtx))
+
+			assert.Equal(t, tt.want, s.messages())
+			assert.Equal(t, tt.dropped, a.Dropped())
+		})
+	}
+}
+
+func TestAsyncSink_Block(t *testing.T) {
+	s := &gatedSink{gate: make(chan struct{}), started: make(chan struct{}, 10)}
+	a := yall.NewAsyncSink(s, &yall.AsyncSinkOptions{Size: 1})
+
+	assert.Nil(t, a.Handle(someCtx, msgRec(someTime, "0")))
+	<-s.started
+	assert.Nil(t, a.Handle(someCtx, msgRec(someTime, "1")))
+
+	ctx, cancel := context.WithTimeout(someCtx, 10*time.Millisecond)
+	defer cancel()
+	err := a.Handle(ctx, msgRec(someTime, "2"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	err = a.Flush(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(s.gate)
+	assert.Nil(t, a.Handle(someCtx, msgRec(someTime, "3")))
+	assert.Nil(t, a.Flush(someCtx))
+	assert.Equal(t, []string{"0", "1", "3"}, s.messages())
+	assert.Zero(t, a.Dropped())
+	assert.Nil(t, a.Close(someCtx))
+}
+
+func TestAsyncSink_Close(t *testing.T) {
+	s := &gatedSink{gate: make(chan struct{})}
+	a := yall.NewAsyncSink(s, nil)
+	assert.Nil(t, a.Handle(someCtx, msgRec(someTime, "0")))
+
+	ctx, cancel := context.WithTimeout(someCtx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, a.Close(ctx), context.DeadlineExceeded)
+
+	close(s.gate)
+	assert.Nil(t, a.Close(someCtx))
+	assert.Equal(t, []string{"0"}, s.messages())
+}
+
+func TestAsyncSink_OnError(t *testing.T) {
+	e := errors.New("e")
+	var errs []error
+	s := &gatedSink{err: e}
+	a := yall.NewAsyncSink(s, &yall.AsyncSinkOptions{OnError: func(err error) {
+		errs = append(errs, err)
+	}})
+	assert.Nil(t, a.Handle(someCtx, msgRec(someTime, "0")))
+	assert.Nil(t, a.Close(someCtx))
+	assert.Equal(t, []error{e}, errs)
+}
+
+func TestAsyncSink_FlushClose(t *testing.T) {
+	s := &lifecycleSink{testSink: testSink{enabled: true}}
+	a := yall.NewAsyncSink(s, nil)
+	assert.Nil(t, a.Handle(someCtx, rec()))
+	assert.Nil(t, a.Flush(someCtx))
+	assert.Equal(t, 1, len(s.calls))
+	assert.Equal(t, 1, s.flushes)
+	assert.Nil(t, a.Close(someCtx))
+	assert.Equal(t, 1, s.closes)
+}
+
+// gatedSink is a thread-safe Sink which optionally waits for gate to be closed
+// before handling each record.
+type gatedSink struct {
+	gate    chan struct{}
+	started chan struct{}
+	err     error
+	lock    sync.Mutex
+	records []slog.Record
+	ctxErrs []error
+}
+
+func (s *gatedSink) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (s *gatedSink) Handle(ctx context.Context, r slog.Record) error {
+	if s.started != nil {
+		s.started <- struct{}{}
+	}
+	if s.gate != nil {
+		<-s.gate
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.records = append(s.records, r)
+	s.ctxErrs = append(s.ctxErrs, ctx.Err())
+	return s.err
+}
+
+func (s *gatedSink) messages() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var m []string
+	for _, r := range s.records {
+		m = append(m, r.Message)
+	}
+	return m
+}
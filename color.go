@@ -0,0 +1,147 @@
+package yall
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// Color is a list of ANSI SGR parameters separated by semicolons, e.g. "1;31" for
+// bold red. The constants below can be combined by joining them with a semicolon.
+type Color string
+
+const (
+	ColorNone      Color = ""
+	ColorBold      Color = "1"
+	ColorDim       Color = "2"
+	ColorItalic    Color = "3"
+	ColorUnderline Color = "4"
+	ColorRed       Color = "31"
+	ColorGreen     Color = "32"
+	ColorYellow    Color = "33"
+	ColorBlue      Color = "34"
+	ColorMagenta   Color = "35"
+	ColorCyan      Color = "36"
+	ColorWhite     Color = "37"
+	ColorGray      Color = "90"
+)
+
+// ColorMode defines when a [WriterSink] enables colors.
+type ColorMode int
+
+const (
+	ColorAuto   = ColorMode(iota) // Enable colors if Writer is a terminal and NO_COLOR is not set.
+	ColorAlways                   // Always enable colors.
+	ColorNever                    // Never enable colors.
+)
+
+type colorKey struct{}
+
+// WithColor returns a copy of ctx in which colors are enabled or disabled.
+// Formatters that produce colors check the setting with [ColorEnabled].
+func WithColor(ctx context.Context, enabled bool) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, colorKey{}, enabled)
+}
+
+// ColorEnabled reports whether colors are enabled in ctx.
+// Colors are disabled unless enabled with [WithColor], so that sinks which don't
+// write to a terminal don't produce escape sequences.
+func ColorEnabled(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	enabled, _ := ctx.Value(colorKey{}).(bool)
+	return enabled
+}
+
+// DefaultLevelColors returns a level to color map suitable for [Colorize.Levels]:
+// debug is gray, info is green, warn is yellow, and error is red.
+func DefaultLevelColors() map[slog.Level]Color {
+	return map[slog.Level]Color{
+		slog.LevelDebug: ColorGray,
+		slog.LevelInfo:  ColorGreen,
+		slog.LevelWarn:  ColorYellow,
+		slog.LevelError: ColorRed,
+	}
+}
+
+// Colorize is a [Formatter] that wraps the output of Inner in ANSI color escape sequences.
+//
+// If Levels is not empty, the color is chosen by [slog.Record.Level]: the entry with
+// the greatest level not exceeding the record level is used. Color is used if Levels
+// is empty or no entry matches.
+//
+// Nothing is added if the output of Inner is empty, the color is [ColorNone],
+// or colors are disabled in the context.
+//
+// Note that [Layout] pads using byte counts, so a padded format such as "%5s" must be
+// applied inside Colorize rather than to its output.
+type Colorize struct {
+	Inner  Formatter
+	Color  Color
+	Levels map[slog.Level]Color
+}
+
+func (co Colorize) Append(b []byte, c context.Context, r slog.Record) []byte {
+	color := co.color(r.Level)
+	if color == ColorNone || !ColorEnabled(c) {
+		return co.Inner.Append(b, c, r)
+	}
+	start := len(b)
+	b = appendColorStart(b, color)
+	mark := len(b)
+	b = co.Inner.Append(b, c, r)
+	if len(b) == mark {
+		return b[:start]
+	}
+	return appendColorEnd(b)
+}
+
+func (co Colorize) color(l slog.Level) Color {
+	if color, found := nearestLevel(co.Levels, l); found {
+		return color
+	}
+	return co.Color
+}
+
+func appendColorStart(b []byte, c Color) []byte {
+	b = append(b, "\x1b["...)
+	b = append(b, c...)
+	return append(b, 'm')
+}
+
+func appendColorEnd(b []byte) []byte {
+	return append(b, "\x1b[0m"...)
+}
+
+// colorFor returns c if colors are enabled in ctx, or ColorNone otherwise.
+func colorFor(ctx context.Context, c Color) Color {
+	if c == ColorNone || !ColorEnabled(ctx) {
+		return ColorNone
+	}
+	return c
+}
+
+// useColor decides whether colors should be enabled for w in the given mode.
+func useColor(w io.Writer, mode ColorMode) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	return os.Getenv("NO_COLOR") == "" && isTerminal(w)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
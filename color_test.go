@@ -0,0 +1,139 @@
+package yall_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"github.com/snake-scaly/yall"
+	"testing"
+)
+
+func TestColorize_Append(t *testing.T) {
+	levels := yall.DefaultLevelColors()
+
+	tests := []struct {
+		name  string
+		f     yall.Colorize
+		ctx   context.Context
+		level slog.Level
+		want  string
+	}{
+		{
+			name: "Color",
+			f:    yall.Colorize{Inner: testFormatter{"x"}, Color: yall.ColorBold},
+			want: "\x1b[1mx\x1b[0m",
+		},
+		{
+			name: "NoColor",
+			f:    yall.Colorize{Inner: testFormatter{"x"}},
+			want: "x",
+		},
+		{
+			name: "EmptyInner",
+			f:    yall.Colorize{Inner: testFormatter{""}, Color: yall.ColorBold},
+			want: "",
+		},
+		{
+			name: "NotEnabled",
+			f:    yall.Colorize{Inner: testFormatter{"x"}, Color: yall.ColorBold},
+			ctx:  someCtx,
+			want: "x",
+		},
+		{
+			name: "Disabled",
+			f:    yall.Colorize{Inner: testFormatter{"x"}, Color: yall.ColorBold},
+			ctx:  yall.WithColor(someCtx, false),
+			want: "x",
+		},
+		{
+			name: "Reenabled",
+			f:    yall.Colorize{Inner: testFormatter{"x"}, Color: yall.ColorBold},
+			ctx:  yall.WithColor(yall.WithColor(someCtx, false), true),
+			want: "\x1b[1mx\x1b[0m",
+		},
+		{
+			name:  "LevelExact",
+			f:     yall.Colorize{Inner: testFormatter{"x"}, Levels: levels},
+			level: slog.LevelWarn,
+			want:  "\x1b[33mx\x1b[0m",
+		},
+		{
+			name:  "LevelRange",
+			f:     yall.Colorize{Inner: testFormatter{"x"}, Levels: levels},
+			level: slog.LevelError + 4,
+			want:  "\x1b[31mx\x1b[0m",
+		},
+		{
+			name:  "LevelBelowAll",
+			f:     yall.Colorize{Inner: testFormatter{"x"}, Color: yall.ColorDim, Levels: levels},
+			level: slog.LevelDebug - 4,
+			want:  "\x1b[2mx\x1b[0m",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = yall.WithColor(someCtx, true)
+			}
+			r := slog.NewRecord(someTime, tt.level, "msg", 0)
+			s := formatToString(tt.f, ctx, r)
+			assert.Equal(t, tt.want, s)
+		})
+	}
+}
+
+func TestTextAttrs_KeyColor(t *testing.T) {
+	f := yall.TextAttrs{KeyColor: yall.ColorDim}
+	r := rec("a", "b", slog.Group("g", "c", "d"))
+
+	s := formatToString(f, yall.WithColor(someCtx, true), r)
+	assert.Equal(t, " \x1b[2ma\x1b[0m=b \x1b[2mg.c\x1b[0m=d", s)
+
+	s = formatToString(f, yall.WithColor(someCtx, false), r)
+	assert.Equal(t, " a=b g.c=d", s)
+
+	s = formatToString(f, someCtx, r)
+	assert.Equal(t, " a=b g.c=d", s)
+}
+
+func TestWriterSink_Color(t *testing.T) {
+	tests := []struct {
+		name string
+		mode yall.ColorMode
+		want string
+	}{
+		{
+			name: "AutoNotTerminal",
+			mode: yall.ColorAuto,
+			want: "msg\n",
+		},
+		{
+			name: "Always",
+			mode: yall.ColorAlways,
+			want: "\x1b[1mmsg\x1b[0m\n",
+		},
+		{
+			name: "Never",
+			mode: yall.ColorNever,
+			want: "msg\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			s := yall.WriterSink{
+				Writer: &buf,
+				Level:  slog.LevelInfo,
+				Format: yall.Colorize{Inner: yall.Message{}, Color: yall.ColorBold},
+				Color:  tt.mode,
+			}
+			err := s.Handle(someCtx, rec())
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
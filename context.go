@@ -0,0 +1,55 @@
+package yall
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+type contextAttrsKey struct{}
+
+// WithContextAttrs returns a copy of ctx carrying attributes which the handler adds to every
+// record logged with this context, see [HandlerOptions.ContextAttrs]. The arguments are
+// converted to attributes the same way as by [slog.Logger.With]. The attributes are added
+// after the ones already carried by ctx.
+func WithContextAttrs(ctx context.Context, args ...any) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	attrs := slog.Group("", args...).Value.Group()
+	if len(attrs) == 0 {
+		return ctx
+	}
+	attrs = append(slices.Clip(ContextAttrs(ctx)), attrs...)
+	return context.WithValue(ctx, contextAttrsKey{}, attrs)
+}
+
+// ContextAttrs returns the attributes added to ctx with [WithContextAttrs].
+// The caller must not modify the returned slice.
+func ContextAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(contextAttrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// ContextValue is a [Formatter] that formats the value stored in the context under Key,
+// as returned by [context.Context.Value]. The value is converted to text like an attribute
+// value with [slog.AnyValue] and quoted according to Quote. The result is empty if
+// the context has no value for Key, which can be combined with [Conditional].
+type ContextValue struct {
+	Key   any
+	Quote QuoteType
+}
+
+func (cv ContextValue) Append(b []byte, c context.Context, _ slog.Record) []byte {
+	if c == nil {
+		return b
+	}
+	v := c.Value(cv.Key)
+	if v == nil {
+		return b
+	}
+	return quote(b, slog.AnyValue(v).Resolve().String(), cv.Quote)
+}
@@ -0,0 +1,71 @@
+package yall_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"github.com/snake-scaly/yall"
+	"testing"
+)
+
+func TestWithContextAttrs(t *testing.T) {
+	ctx := yall.WithContextAttrs(nil, "a", 1)
+	ctx1 := yall.WithContextAttrs(ctx, slog.String("b", "x"))
+	ctx2 := yall.WithContextAttrs(ctx, "c", true)
+
+	assert.Equal(t, []slog.Attr{slog.Int("a", 1)}, yall.ContextAttrs(ctx))
+	assert.Equal(t, []slog.Attr{slog.Int("a", 1), slog.String("b", "x")}, yall.ContextAttrs(ctx1))
+	assert.Equal(t, []slog.Attr{slog.Int("a", 1), slog.Bool("c", true)}, yall.ContextAttrs(ctx2))
+	assert.Equal(t, ctx, yall.WithContextAttrs(ctx))
+	assert.Nil(t, yall.ContextAttrs(someCtx))
+	assert.Nil(t, yall.ContextAttrs(nil))
+}
+
+type ctxKey struct{}
+
+func TestContextValue_Append(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		cv   yall.ContextValue
+		want string
+	}{
+		{
+			name: "NilContext",
+			ctx:  nil,
+			cv:   yall.ContextValue{Key: ctxKey{}},
+			want: "",
+		},
+		{
+			name: "Missing",
+			ctx:  someCtx,
+			cv:   yall.ContextValue{Key: ctxKey{}},
+			want: "",
+		},
+		{
+			name: "String",
+			ctx:  context.WithValue(someCtx, ctxKey{}, "req 42"),
+			cv:   yall.ContextValue{Key: ctxKey{}, Quote: yall.QuoteSmart},
+			want: `"req 42"`,
+		},
+		{
+			name: "Int",
+			ctx:  context.WithValue(someCtx, ctxKey{}, 42),
+			cv:   yall.ContextValue{Key: ctxKey{}},
+			want: "42",
+		},
+		{
+			name: "LogValuer",
+			ctx:  context.WithValue(someCtx, ctxKey{}, testLogValuer{slog.StringValue("v")}),
+			cv:   yall.ContextValue{Key: ctxKey{}, Quote: yall.QuoteJSON},
+			want: `"v"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := formatToString(tt.cv, tt.ctx, rec())
+			assert.Equal(t, tt.want, s)
+		})
+	}
+}
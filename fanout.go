@@ -9,9 +9,14 @@ import (
 	"sync/atomic"
 )
 
-var _ Sink = (*FanOutSink)(nil)
+var (
+	_ Sink    = (*FanOutSink)(nil)
+	_ Flusher = (*FanOutSink)(nil)
+	_ Closer  = (*FanOutSink)(nil)
+)
 
 // FanOutSink is a Sink that broadcasts logging events to a dynamic list of other sinks.
+// Flush and Close are propagated to all the sinks that implement [Flusher] and [Closer].
 type FanOutSink struct {
 	sinks     atomic.Value
 	writeLock sync.Mutex
@@ -79,6 +84,24 @@ func (f *FanOutSink) Handle(ctx context.Context, record slog.Record) error {
 	return errors.Join(errs...)
 }
 
+func (f *FanOutSink) Flush(ctx context.Context) error {
+	sinks := f.getSinks()
+	errs := make([]error, len(sinks))
+	for i, s := range sinks {
+		errs[i] = Flush(ctx, s)
+	}
+	return errors.Join(errs...)
+}
+
+func (f *FanOutSink) Close(ctx context.Context) error {
+	sinks := f.getSinks()
+	errs := make([]error, len(sinks))
+	for i, s := range sinks {
+		errs[i] = Close(ctx, s)
+	}
+	return errors.Join(errs...)
+}
+
 func (f *FanOutSink) getSinks() []Sink {
 	return f.sinks.Load().([]Sink)
 }
@@ -216,6 +216,27 @@ func TestFanOutSink_RemoveUnknownSink(t *testing.T) {
 	assert.False(t, removed)
 }
 
+func TestFanOutSink_FlushClose(t *testing.T) {
+	e1 := errors.New("e1")
+	e2 := errors.New("e2")
+	s1 := &lifecycleSink{err: e1}
+	s2 := &testSink{}
+	s3 := &lifecycleSink{err: e2}
+	d := yall.NewFanOutSink(s1, s2, s3)
+
+	err := d.Flush(someCtx)
+	assert.ErrorIs(t, err, e1)
+	assert.ErrorIs(t, err, e2)
+	assert.Equal(t, 1, s1.flushes)
+	assert.Equal(t, 1, s3.flushes)
+
+	err = d.Close(someCtx)
+	assert.ErrorIs(t, err, e1)
+	assert.ErrorIs(t, err, e2)
+	assert.Equal(t, 1, s1.closes)
+	assert.Equal(t, 1, s3.closes)
+}
+
 func newFanOutSinkWithTestSinks(sinks []*testSink) *yall.FanOutSink {
 	ss := make([]yall.Sink, len(sinks))
 	for i, s := range sinks {
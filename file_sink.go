@@ -0,0 +1,404 @@
+package yall
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RotateInterval defines time boundaries on which a [FileSink] rotates its file.
+type RotateInterval int
+
+const (
+	RotateNever  = RotateInterval(iota) // Do not rotate on time boundaries.
+	RotateHourly                        // Rotate when an hour starts.
+	RotateDaily                         // Rotate when a day starts.
+)
+
+// backupTimeFormat is the timestamp format in backup file names.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+var errFileSinkClosed = errors.New("yall: file sink is closed")
+
+var (
+	_ Sink    = (*FileSink)(nil)
+	_ Flusher = (*FileSink)(nil)
+	_ Closer  = (*FileSink)(nil)
+)
+
+// FileSink is a sink that writes logs formatted by Format to a file and rotates the file
+// when it grows too large or when a time boundary is crossed.
+// Each log event is terminated with a new line and is written as a single write
+// on the file. A record is never split between two files.
+//
+// The file is opened in append mode on the first Handle, creating it and its directory
+// if necessary. On rotation the file is renamed to a backup named after the file with
+// a timestamp inserted before the extension, e.g. app-2020-11-22T12-34-56.789.log,
+// and a new file is created. Backups are optionally compressed and removed
+// in the background.
+//
+// Call Close to release the file and wait for background tasks to finish.
+type FileSink struct {
+	Filename string
+	Level    slog.Leveler
+	Format   Formatter
+
+	// MaxSize is the size in bytes the file must not exceed. A record which would make
+	// the file larger goes to a new file. A record larger than MaxSize is still written
+	// to a file of its own. Zero disables size-based rotation.
+	MaxSize int64
+
+	// Interval enables rotation on time boundaries. Boundaries are computed in the time zone
+	// of the wall clock or of the record time, see RecordTime.
+	Interval RotateInterval
+
+	// RecordTime makes time-based rotation and backup names use [slog.Record.Time]
+	// instead of the wall clock. Records with zero time still use the wall clock.
+	RecordTime bool
+
+	// MaxBackups is the number of backups to keep. Zero keeps all backups.
+	MaxBackups int
+
+	// MaxAge is how long to keep backups, based on their modification time.
+	// Zero keeps backups indefinitely.
+	MaxAge time.Duration
+
+	// Compress enables gzip compression of backups.
+	Compress bool
+
+	// ReopenOnSIGHUP makes the sink call Reopen whenever the process receives SIGHUP,
+	// for compatibility with external tools such as logrotate.
+	ReopenOnSIGHUP bool
+
+	lock    sync.Mutex
+	buffer  []byte
+	file    *os.File
+	size    int64
+	period  time.Time
+	closed  bool
+	signals chan os.Signal
+
+	bg      taskGroup
+	bgLock  sync.Mutex
+	bgError error
+}
+
+func (s *FileSink) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= s.Level.Level()
+}
+
+func (s *FileSink) Handle(c context.Context, r slog.Record) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return errFileSinkClosed
+	}
+
+	s.buffer = s.Format.Append(s.buffer[:0], c, r)
+	s.buffer = append(s.buffer, '\n')
+
+	now := time.Now()
+	if s.RecordTime && !r.Time.IsZero() {
+		now = r.Time
+	}
+
+	if s.file == nil {
+		if err := s.open(now); err != nil {
+			return err
+		}
+	}
+	if s.needsRotation(now, len(s.buffer)) {
+		if err := s.rotate(now); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(s.buffer)
+	s.size += int64(n)
+	return err
+}
+
+// Rotate closes the current file, renames it to a backup, and opens a new file.
+func (s *FileSink) Rotate() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return errFileSinkClosed
+	}
+	now := time.Now()
+	if s.file == nil {
+		if err := s.open(now); err != nil {
+			return err
+		}
+	}
+	return s.rotate(now)
+}
+
+// Flush commits the current file to stable storage.
+func (s *FileSink) Flush(context.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Sync()
+}
+
+// Reopen closes the current file. The file is opened again by name on the next Handle.
+// This is useful when the file has been moved by an external tool.
+func (s *FileSink) Reopen() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.closeFile()
+}
+
+// Close closes the file and waits until background compression and removal of backups
+// finishes or ctx is done. Any errors from the background tasks are returned.
+// Handle fails after Close.
+func (s *FileSink) Close(ctx context.Context) error {
+	s.lock.Lock()
+	s.closed = true
+	err := s.closeFile()
+	if s.signals != nil {
+		signal.Stop(s.signals)
+		close(s.signals)
+		s.signals = nil
+	}
+	s.lock.Unlock()
+
+	if werr := s.bg.Wait(ctx); werr != nil {
+		return errors.Join(err, werr)
+	}
+
+	s.bgLock.Lock()
+	defer s.bgLock.Unlock()
+	return errors.Join(err, s.bgError)
+}
+
+func (s *FileSink) open(now time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(s.Filename), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	s.file = f
+	s.size = fi.Size()
+	s.period = s.periodStart(now)
+	if s.size != 0 {
+		// An existing file belongs to the period it was last written in.
+		s.period = s.periodStart(fi.ModTime().In(now.Location()))
+	}
+	if s.ReopenOnSIGHUP && s.signals == nil {
+		s.signals = make(chan os.Signal, 1)
+		signal.Notify(s.signals, syscall.SIGHUP)
+		go s.reopenOnSignal(s.signals)
+	}
+	return nil
+}
+
+func (s *FileSink) reopenOnSignal(signals <-chan os.Signal) {
+	for range signals {
+		_ = s.Reopen()
+	}
+}
+
+func (s *FileSink) closeFile() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileSink) needsRotation(now time.Time, n int) bool {
+	if s.MaxSize > 0 && s.size > 0 && s.size+int64(n) > s.MaxSize {
+		return true
+	}
+	return s.Interval != RotateNever && s.periodStart(now).After(s.period)
+}
+
+func (s *FileSink) periodStart(t time.Time) time.Time {
+	switch s.Interval {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+func (s *FileSink) rotate(now time.Time) error {
+	if err := s.closeFile(); err != nil {
+		return err
+	}
+	backup, err := s.backupName(now)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(s.Filename, backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := s.open(now); err != nil {
+		return err
+	}
+	s.period = s.periodStart(now)
+
+	if s.Compress || s.MaxBackups > 0 || s.MaxAge > 0 {
+		s.bg.Go(func() {
+			s.processBackups(backup)
+		})
+	}
+	return nil
+}
+
+// backupName returns an unused backup file name for the time t.
+func (s *FileSink) backupName(t time.Time) (string, error) {
+	dir, prefix, ext := s.nameParts()
+	stamp := t.Format(backupTimeFormat)
+	for i := 0; ; i++ {
+		name := prefix + stamp
+		if i != 0 {
+			name += "-" + strconv.Itoa(i)
+		}
+		name = filepath.Join(dir, name+ext)
+		_, err := os.Lstat(name)
+		if errors.Is(err, os.ErrNotExist) {
+			_, err = os.Lstat(name + ".gz")
+		}
+		if errors.Is(err, os.ErrNotExist) {
+			return name, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// nameParts splits Filename into the directory, backup name prefix, and extension.
+func (s *FileSink) nameParts() (dir, prefix, ext string) {
+	dir, base := filepath.Split(s.Filename)
+	ext = filepath.Ext(base)
+	return dir, strings.TrimSuffix(base, ext) + "-", ext
+}
+
+func (s *FileSink) processBackups(backup string) {
+	s.bgLock.Lock()
+	defer s.bgLock.Unlock()
+
+	var errs []error
+	if s.Compress {
+		errs = append(errs, compressFile(backup))
+	}
+	errs = append(errs, s.removeOldBackups())
+	if err := errors.Join(errs...); err != nil {
+		s.bgError = errors.Join(s.bgError, err)
+	}
+}
+
+func (s *FileSink) removeOldBackups() error {
+	if s.MaxBackups <= 0 && s.MaxAge <= 0 {
+		return nil
+	}
+
+	dir, prefix, ext := s.nameParts()
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	type backup struct {
+		path    string
+		modTime time.Time
+	}
+	var backups []backup
+	for _, e := range entries {
+		name := e.Name()
+		// Sinks writing to files with the same prefix but another extension
+		// keep their own backups.
+		base := strings.TrimSuffix(name, ".gz")
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(base, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(base, ext)[len(prefix):]
+		if len(stamp) < len(backupTimeFormat) {
+			continue
+		}
+		if _, err := time.Parse(backupTimeFormat, stamp[:len(backupTimeFormat)]); err != nil {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{filepath.Join(dir, name), fi.ModTime()})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].modTime.After(backups[j].modTime)
+	})
+
+	var errs []error
+	cutoff := time.Now().Add(-s.MaxAge)
+	for i, b := range backups {
+		if (s.MaxBackups > 0 && i >= s.MaxBackups) || (s.MaxAge > 0 && b.modTime.Before(cutoff)) {
+			if err := os.Remove(b.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	if err := writeGzip(name+".gz", src, fi.Mode()); err != nil {
+		_ = os.Remove(name + ".gz")
+		return err
+	}
+	// Keep the modification time so that MaxAge is counted from the last write.
+	_ = os.Chtimes(name+".gz", fi.ModTime(), fi.ModTime())
+	_ = src.Close()
+	return os.Remove(name)
+}
+
+func writeGzip(name string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	z := gzip.NewWriter(f)
+	_, err = io.Copy(z, r)
+	return errors.Join(err, z.Close(), f.Close())
+}
@@ -0,0 +1,241 @@
+package yall_test
+
+import (
+	"compress/gzip"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"github.com/snake-scaly/yall"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileSink_Handle(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestFileSink(filepath.Join(dir, "sub", "app.log"))
+
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "one")))
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "two")))
+	assert.Nil(t, s.Close(someCtx))
+
+	assert.Equal(t, map[string]string{"app.log": "one\ntwo\n"}, readLogDir(t, filepath.Join(dir, "sub")))
+	assert.NotNil(t, s.Handle(someCtx, msgRec(someTime, "three")))
+}
+
+func TestFileSink_Flush(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestFileSink(filepath.Join(dir, "app.log"))
+	assert.Nil(t, s.Flush(someCtx))
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "one")))
+	assert.Nil(t, s.Flush(someCtx))
+	assert.Equal(t, map[string]string{"app.log": "one\n"}, readLogDir(t, dir))
+	assert.Nil(t, s.Close(someCtx))
+}
+
+func TestFileSink_Append(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	require.Nil(t, os.WriteFile(name, []byte("old\n"), 0o644))
+
+	s := newTestFileSink(name)
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "new")))
+	assert.Nil(t, s.Close(someCtx))
+
+	assert.Equal(t, map[string]string{"app.log": "old\nnew\n"}, readLogDir(t, dir))
+}
+
+func TestFileSink_NoColor(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestFileSink(filepath.Join(dir, "app.log"))
+	s.Format = yall.Colorize{Inner: yall.Message{}, Color: yall.ColorRed}
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "one")))
+	assert.Nil(t, s.Close(someCtx))
+
+	assert.Equal(t, map[string]string{"app.log": "one\n"}, readLogDir(t, dir))
+}
+
+func TestFileSink_MaxSize(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestFileSink(filepath.Join(dir, "app.log"))
+	s.MaxSize = 10
+
+	for _, m := range []string{"1234", "5678", "abcdefghijklmnop", "x"} {
+		assert.Nil(t, s.Handle(someCtx, msgRec(someTime, m)))
+	}
+	assert.Nil(t, s.Close(someCtx))
+
+	files := readLogDir(t, dir)
+	assert.Equal(t, "x\n", files["app.log"])
+	assert.ElementsMatch(t, []string{"1234\n5678\n", "abcdefghijklmnop\n"}, backupContents(files))
+}
+
+func TestFileSink_Interval(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestFileSink(filepath.Join(dir, "app.log"))
+	s.Interval = yall.RotateHourly
+	s.RecordTime = true
+
+	t0 := time.Date(2020, 11, 22, 12, 58, 0, 0, time.UTC)
+	assert.Nil(t, s.Handle(someCtx, msgRec(t0, "a")))
+	assert.Nil(t, s.Handle(someCtx, msgRec(t0.Add(time.Minute), "b")))
+	assert.Nil(t, s.Handle(someCtx, msgRec(t0.Add(2*time.Minute), "c")))
+	assert.Nil(t, s.Handle(someCtx, msgRec(t0.Add(time.Minute), "late")))
+	assert.Nil(t, s.Handle(someCtx, msgRec(t0.Add(62*time.Minute), "d")))
+	assert.Nil(t, s.Close(someCtx))
+
+	files := readLogDir(t, dir)
+	assert.Equal(t, "d\n", files["app.log"])
+	assert.Equal(t, "a\nb\n", files["app-2020-11-22T13-00-00.000.log"])
+	assert.Equal(t, "c\nlate\n", files["app-2020-11-22T14-00-00.000.log"])
+}
+
+func TestFileSink_MaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestFileSink(filepath.Join(dir, "app.log"))
+	s.MaxBackups = 2
+
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, s.Handle(someCtx, msgRec(someTime, strings.Repeat("x", i+1))))
+		assert.Nil(t, s.Rotate())
+		// make modification times distinct
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, s.Close(someCtx))
+
+	files := readLogDir(t, dir)
+	assert.Equal(t, "", files["app.log"])
+	assert.Equal(t, []string{"xxxx\n", "xxxxx\n"}, backupContents(files))
+}
+
+func TestFileSink_MaxBackupsOtherExt(t *testing.T) {
+	dir := t.TempDir()
+	sibling := filepath.Join(dir, "app-2000-01-01T00-00-00.000.json")
+	require.Nil(t, os.WriteFile(sibling, []byte("keep\n"), 0o644))
+	require.Nil(t, os.Chtimes(sibling, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+
+	s := newTestFileSink(filepath.Join(dir, "app.log"))
+	s.MaxBackups = 1
+	s.MaxAge = time.Minute
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "a")))
+	assert.Nil(t, s.Rotate())
+	assert.Nil(t, s.Close(someCtx))
+
+	files := readLogDir(t, dir)
+	assert.Equal(t, "keep\n", files[filepath.Base(sibling)])
+	assert.Len(t, files, 3)
+}
+
+func TestFileSink_MaxAge(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "app-2000-01-01T00-00-00.000.log")
+	require.Nil(t, os.WriteFile(old, []byte("old\n"), 0o644))
+	require.Nil(t, os.Chtimes(old, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+	unrelated := filepath.Join(dir, "app-unrelated.log")
+	require.Nil(t, os.WriteFile(unrelated, []byte("keep\n"), 0o644))
+
+	s := newTestFileSink(filepath.Join(dir, "app.log"))
+	s.MaxAge = time.Minute
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "new")))
+	assert.Nil(t, s.Rotate())
+	assert.Nil(t, s.Close(someCtx))
+
+	files := readLogDir(t, dir)
+	assert.NotContains(t, files, "app-2000-01-01T00-00-00.000.log")
+	assert.Contains(t, files, "app-unrelated.log")
+	assert.Equal(t, []string{"new\n"}, backupContents(files))
+}
+
+func TestFileSink_Compress(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestFileSink(filepath.Join(dir, "app.log"))
+	s.Compress = true
+
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "compressed")))
+	assert.Nil(t, s.Rotate())
+	assert.Nil(t, s.Close(someCtx))
+
+	files := readLogDir(t, dir)
+	assert.Len(t, files, 2)
+	for name, content := range files {
+		if name != "app.log" {
+			assert.True(t, strings.HasSuffix(name, ".log.gz"), name)
+			assert.Equal(t, "compressed\n", content)
+		}
+	}
+}
+
+func TestFileSink_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	s := newTestFileSink(name)
+
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "before")))
+	require.Nil(t, os.Rename(name, filepath.Join(dir, "moved.log")))
+	assert.Nil(t, s.Reopen())
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "after")))
+	assert.Nil(t, s.Close(someCtx))
+
+	assert.Equal(t, map[string]string{"app.log": "after\n", "moved.log": "before\n"}, readLogDir(t, dir))
+}
+
+func TestFileSink_CloseContext(t *testing.T) {
+	s := newTestFileSink(filepath.Join(t.TempDir(), "app.log"))
+	ctx, cancel := context.WithCancel(someCtx)
+	cancel()
+	assert.Nil(t, s.Close(ctx))
+}
+
+func newTestFileSink(name string) *yall.FileSink {
+	return &yall.FileSink{
+		Filename: name,
+		Level:    slog.LevelInfo,
+		Format:   yall.Message{},
+	}
+}
+
+func msgRec(t time.Time, msg string) slog.Record {
+	return slog.NewRecord(t, slog.LevelInfo, msg, 0)
+}
+
+// readLogDir returns contents of all files in dir, decompressing gzipped files.
+func readLogDir(t *testing.T, dir string) map[string]string {
+	entries, err := os.ReadDir(dir)
+	require.Nil(t, err)
+	files := map[string]string{}
+	for _, e := range entries {
+		f, err := os.Open(filepath.Join(dir, e.Name()))
+		require.Nil(t, err)
+		var r io.Reader = f
+		if strings.HasSuffix(e.Name(), ".gz") {
+			r, err = gzip.NewReader(f)
+			require.Nil(t, err)
+		}
+		b, err := io.ReadAll(r)
+		require.Nil(t, err)
+		_ = f.Close()
+		files[e.Name()] = string(b)
+	}
+	return files
+}
+
+// backupContents returns contents of backup files ordered by name.
+func backupContents(files map[string]string) []string {
+	var names []string
+	for name := range files {
+		if strings.HasPrefix(name, "app-") && name != "app-unrelated.log" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var contents []string
+	for _, name := range names {
+		contents = append(contents, files[name])
+	}
+	return contents
+}
@@ -0,0 +1,140 @@
+package yall
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Predicate reports whether a record should be handled.
+type Predicate func(c context.Context, r slog.Record) bool
+
+var (
+	_ Sink    = (*FilterSink)(nil)
+	_ Flusher = (*FilterSink)(nil)
+	_ Closer  = (*FilterSink)(nil)
+)
+
+// FilterSink is a Sink that passes to Sink only the records with levels between MinLevel
+// and MaxLevel inclusive, for which Predicate returns true. Nil MinLevel, MaxLevel, or
+// Predicate don't restrict records. This allows filtering sinks which have no level of
+// their own, such as [FanOutSink] children or slog handlers.
+//
+// Flush and Close are propagated to Sink if it implements [Flusher] and [Closer].
+type FilterSink struct {
+	Sink      Sink
+	MinLevel  slog.Leveler
+	MaxLevel  slog.Leveler
+	Predicate Predicate
+}
+
+func (s *FilterSink) Enabled(c context.Context, l slog.Level) bool {
+	return s.levelEnabled(l) && s.Sink.Enabled(c, l)
+}
+
+func (s *FilterSink) Handle(c context.Context, r slog.Record) error {
+	if !s.levelEnabled(r.Level) || (s.Predicate != nil && !s.Predicate(c, r)) {
+		return nil
+	}
+	return s.Sink.Handle(c, r)
+}
+
+func (s *FilterSink) Flush(c context.Context) error {
+	return Flush(c, s.Sink)
+}
+
+func (s *FilterSink) Close(c context.Context) error {
+	return Close(c, s.Sink)
+}
+
+func (s *FilterSink) levelEnabled(l slog.Level) bool {
+	if s.MinLevel != nil && l < s.MinLevel.Level() {
+		return false
+	}
+	if s.MaxLevel != nil && l > s.MaxLevel.Level() {
+		return false
+	}
+	return true
+}
+
+// MessageMatches returns a [Predicate] accepting records whose message matches re.
+func MessageMatches(re *regexp.Regexp) Predicate {
+	return func(_ context.Context, r slog.Record) bool {
+		return re.MatchString(r.Message)
+	}
+}
+
+// HasAttr returns a [Predicate] accepting records with an attribute with the given key.
+// Keys of attributes in groups are joined with dots, e.g. "req.id".
+func HasAttr(key string) Predicate {
+	return func(_ context.Context, r slog.Record) bool {
+		_, found := recordAttr(r, key)
+		return found
+	}
+}
+
+// AttrEquals returns a [Predicate] accepting records with an attribute with the given key,
+// named like in [HasAttr], whose value equals value converted with [slog.AnyValue].
+func AttrEquals(key string, value any) Predicate {
+	want := slog.AnyValue(value).Resolve()
+	return func(_ context.Context, r slog.Record) bool {
+		v, found := recordAttr(r, key)
+		return found && v.Equal(want)
+	}
+}
+
+// SourcePackage returns a [Predicate] accepting records logged from the package with
+// the given import path or from its subpackages. Records without a PC are rejected.
+func SourcePackage(path string) Predicate {
+	return func(_ context.Context, r slog.Record) bool {
+		if r.PC == 0 {
+			return false
+		}
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		pkg := packagePath(f.Function)
+		return pkg == path || strings.HasPrefix(pkg, path+"/")
+	}
+}
+
+// Not returns a [Predicate] accepting the records rejected by p.
+func Not(p Predicate) Predicate {
+	return func(c context.Context, r slog.Record) bool {
+		return !p(c, r)
+	}
+}
+
+// AllOf returns a [Predicate] accepting the records accepted by all of ps.
+func AllOf(ps ...Predicate) Predicate {
+	return func(c context.Context, r slog.Record) bool {
+		for _, p := range ps {
+			if !p(c, r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AnyOf returns a [Predicate] accepting the records accepted by any of ps.
+func AnyOf(ps ...Predicate) Predicate {
+	return func(c context.Context, r slog.Record) bool {
+		for _, p := range ps {
+			if p(c, r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// packagePath returns the import path of the package of the full function name fn.
+func packagePath(fn string) string {
+	// As in funcName, the first dot after the last slash ends the package path.
+	slash := strings.LastIndexByte(fn, '/') + 1
+	if dot := strings.IndexByte(fn[slash:], '.'); dot >= 0 {
+		return fn[:slash+dot]
+	}
+	return fn
+}
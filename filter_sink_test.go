@@ -0,0 +1,259 @@
+package yall_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"regexp"
+	"github.com/snake-scaly/yall"
+	"testing"
+)
+
+func TestFilterSink_Enabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		min     slog.Leveler
+		max     slog.Leveler
+		inner   bool
+		level   slog.Level
+		enabled bool
+	}{
+		{
+			name:    "NoLimits",
+			inner:   true,
+			level:   slog.LevelDebug,
+			enabled: true,
+		},
+		{
+			name:    "InnerDisabled",
+			inner:   false,
+			level:   slog.LevelInfo,
+			enabled: false,
+		},
+		{
+			name:    "BelowMin",
+			min:     slog.LevelInfo,
+			inner:   true,
+			level:   slog.LevelDebug,
+			enabled: false,
+		},
+		{
+			name:    "AtMin",
+			min:     slog.LevelInfo,
+			inner:   true,
+			level:   slog.LevelInfo,
+			enabled: true,
+		},
+		{
+			name:    "AtMax",
+			max:     slog.LevelWarn,
+			inner:   true,
+			level:   slog.LevelWarn,
+			enabled: true,
+		},
+		{
+			name:    "AboveMax",
+			max:     slog.LevelWarn,
+			inner:   true,
+			level:   slog.LevelError,
+			enabled: false,
+		},
+		{
+			name:    "Between",
+			min:     slog.LevelInfo,
+			max:     slog.LevelWarn,
+			inner:   true,
+			level:   yall.LevelNotice,
+			enabled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &yall.FilterSink{Sink: &testSink{enabled: tt.inner}, MinLevel: tt.min, MaxLevel: tt.max}
+			assert.Equal(t, tt.enabled, s.Enabled(someCtx, tt.level))
+		})
+	}
+}
+
+func TestFilterSink_Handle(t *testing.T) {
+	tests := []struct {
+		name      string
+		max       slog.Leveler
+		predicate yall.Predicate
+		rec       slog.Record
+		handled   bool
+	}{
+		{
+			name:    "NoPredicate",
+			rec:     rec(),
+			handled: true,
+		},
+		{
+			name:    "AboveMax",
+			max:     slog.LevelDebug,
+			rec:     rec(),
+			handled: false,
+		},
+		{
+			name:      "MessageMatch",
+			predicate: yall.MessageMatches(regexp.MustCompile("^m.g$")),
+			rec:       rec(),
+			handled:   true,
+		},
+		{
+			name:      "MessageMismatch",
+			predicate: yall.MessageMatches(regexp.MustCompile("other")),
+			rec:       rec(),
+			handled:   false,
+		},
+		{
+			name:      "HasAttr",
+			predicate: yall.HasAttr("a"),
+			rec:       rec("a", 1),
+			handled:   true,
+		},
+		{
+			name:      "HasAttrInGroup",
+			predicate: yall.HasAttr("g.a"),
+			rec:       rec(slog.Group("g", "a", 1)),
+			handled:   true,
+		},
+		{
+			name:      "NoAttr",
+			predicate: yall.HasAttr("a"),
+			rec:       rec("b", 1),
+			handled:   false,
+		},
+		{
+			name:      "AttrEquals",
+			predicate: yall.AttrEquals("a", 1),
+			rec:       rec("a", int64(1)),
+			handled:   true,
+		},
+		{
+			name:      "AttrEqualsString",
+			predicate: yall.AttrEquals("g.a", "x"),
+			rec:       rec(slog.Group("g", "a", "x")),
+			handled:   true,
+		},
+		{
+			name:      "AttrEqualsValuer",
+			predicate: yall.AttrEquals("a", "x"),
+			rec:       rec("a", testLogValuer{slog.StringValue("x")}),
+			handled:   true,
+		},
+		{
+			name:      "AttrDiffers",
+			predicate: yall.AttrEquals("a", 1),
+			rec:       rec("a", 2),
+			handled:   false,
+		},
+		{
+			name:      "AttrDiffersByKind",
+			predicate: yall.AttrEquals("a", 1),
+			rec:       rec("a", "1"),
+			handled:   false,
+		},
+		{
+			name:      "AttrMissing",
+			predicate: yall.AttrEquals("a", 1),
+			rec:       rec(),
+			handled:   false,
+		},
+		{
+			name:      "Package",
+			predicate: yall.SourcePackage("github.com/snake-scaly/yall_test"),
+			rec:       rec(),
+			handled:   true,
+		},
+		{
+			name:      "ParentPackage",
+			predicate: yall.SourcePackage("github.com/snake-scaly"),
+			rec:       rec(),
+			handled:   true,
+		},
+		{
+			name:      "PackagePrefix",
+			predicate: yall.SourcePackage("github.com/snake-scaly/yall"),
+			rec:       rec(),
+			handled:   false,
+		},
+		{
+			name:      "PackageWithoutPC",
+			predicate: yall.SourcePackage("github.com/snake-scaly"),
+			rec:       msgRec(someTime, "m"),
+			handled:   false,
+		},
+		{
+			name:      "Not",
+			predicate: yall.Not(yall.HasAttr("a")),
+			rec:       rec("a", 1),
+			handled:   false,
+		},
+		{
+			name:      "AllOf",
+			predicate: yall.AllOf(yall.HasAttr("a"), yall.HasAttr("b")),
+			rec:       rec("a", 1, "b", 2),
+			handled:   true,
+		},
+		{
+			name:      "AllOfFails",
+			predicate: yall.AllOf(yall.HasAttr("a"), yall.HasAttr("b")),
+			rec:       rec("a", 1),
+			handled:   false,
+		},
+		{
+			name:      "AnyOf",
+			predicate: yall.AnyOf(yall.HasAttr("a"), yall.HasAttr("b")),
+			rec:       rec("b", 1),
+			handled:   true,
+		},
+		{
+			name:      "AnyOfFails",
+			predicate: yall.AnyOf(yall.HasAttr("a"), yall.HasAttr("b")),
+			rec:       rec("c", 1),
+			handled:   false,
+		},
+		{
+			name:      "Context",
+			predicate: func(c context.Context, _ slog.Record) bool { return c.Value(ctxKey{}) == "v" },
+			rec:       rec(),
+			handled:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &testSink{enabled: true}
+			s := &yall.FilterSink{Sink: inner, MaxLevel: tt.max, Predicate: tt.predicate}
+			ctx := context.WithValue(someCtx, ctxKey{}, "v")
+			assert.Nil(t, s.Handle(ctx, tt.rec))
+			if tt.handled {
+				assert.Len(t, inner.calls, 1)
+			} else {
+				assert.Empty(t, inner.calls)
+			}
+		})
+	}
+}
+
+func TestFilterSink_Error(t *testing.T) {
+	e := errors.New("test")
+	s := &yall.FilterSink{Sink: &testSink{enabled: true, err: e}}
+	assert.ErrorIs(t, s.Handle(someCtx, rec()), e)
+}
+
+func TestFilterSink_FlushClose(t *testing.T) {
+	e := errors.New("test")
+	inner := &lifecycleSink{err: e}
+	s := &yall.FilterSink{Sink: inner}
+
+	assert.ErrorIs(t, s.Flush(someCtx), e)
+	assert.ErrorIs(t, s.Close(someCtx), e)
+	assert.Equal(t, 1, inner.flushes)
+	assert.Equal(t, 1, inner.closes)
+
+	s = &yall.FilterSink{Sink: &testSink{}}
+	assert.Nil(t, s.Flush(someCtx))
+	assert.Nil(t, s.Close(someCtx))
+}
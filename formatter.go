@@ -4,9 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"path"
-	"runtime"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 	"unicode"
@@ -19,6 +19,8 @@ const (
 	QuoteNever  = QuoteType(iota) // Do not add quotes.
 	QuoteAlways                   // Always quote using [strconv.Quote].
 	QuoteSmart                    // Only quote empty strings and strings containing spaces and/or equal signs.
+	QuoteJSON                     // Always quote as a JSON string.
+	QuoteLogfmt                   // Quote as a logfmt value, see [Logfmt].
 )
 
 // Formatter creates a text representation of a [slog.Record].
@@ -59,40 +61,29 @@ func (l Layout) Append(b []byte, c context.Context, r slog.Record) []byte {
 // See [time.Layout] and related constants for a list of existing formats and a discussion
 // on how to create your own.
 // The zero value formats with [time.DateTime].
+// Zero [slog.Record.Time] produces an empty string, which can be combined with [Conditional].
 type Time struct {
 	Layout string
 }
 
 func (t Time) Append(b []byte, _ context.Context, r slog.Record) []byte {
+	if r.Time.IsZero() {
+		return b
+	}
 	if t.Layout == "" {
 		return r.Time.AppendFormat(b, time.DateTime)
 	}
 	return r.Time.AppendFormat(b, t.Layout)
 }
 
-// Level is a [Formatter] that formats [slog.Record.Level] using [slog.Level.String].
-type Level struct{}
-
-func (l Level) Append(b []byte, _ context.Context, r slog.Record) []byte {
-	return fmt.Append(b, r.Level.String())
+// Level is a [Formatter] that formats [slog.Record.Level] using [LevelNames.Name].
+// If Names is nil, levels are formatted with [slog.Level.String].
+type Level struct {
+	Names *LevelNames
 }
 
-// Source is a [Formatter] that formats [slog.Record.PC]. Set Short to true to only print
-// source file name without path.
-type Source struct {
-	Short bool
-}
-
-func (s Source) Append(b []byte, _ context.Context, r slog.Record) []byte {
-	fn := runtime.FuncForPC(r.PC)
-	if fn == nil {
-		return b
-	}
-	f, l := fn.FileLine(r.PC)
-	if s.Short {
-		f = path.Base(f)
-	}
-	return fmt.Append(b, f, ":", l)
+func (l Level) Append(b []byte, _ context.Context, r slog.Record) []byte {
+	return append(b, levelName(l.Names, r.Level)...)
 }
 
 // Message is a [Formatter] that formats [slog.Record.Message].
@@ -106,20 +97,94 @@ func (m Message) Append(b []byte, _ context.Context, r slog.Record) []byte {
 }
 
 // TextAttrs is a [Formatter] that formats [slog.Record.Attrs] as "key=value" pairs.
-// Values are quoted according to Quote.
+// Values are resolved with [slog.Value.Resolve], and group values are flattened
+// into dot-separated keys. Empty attrs and empty groups are omitted, and groups
+// with empty keys are inlined.
+// Values are quoted according to Quote. Keys are colored with KeyColor if colors
+// are enabled in the context, see [WithColor].
 // When the result is non-empty, it includes a leading space.
 type TextAttrs struct {
-	Quote QuoteType
+	Quote    QuoteType
+	KeyColor Color
+
+	// Exclude lists dot-separated keys of attrs which are not formatted, e.g. because
+	// they are formatted elsewhere by [Attr]. Excluding a group key excludes the whole group.
+	Exclude []string
+
+	// Indent enables a multi-line layout intended for humans. Groups are printed
+	// as "key:" followed by their attrs on separate lines, indented with one more Indent
+	// per nesting level, instead of using dotted keys. Values containing new lines are
+	// printed unquoted below their keys, one more Indent deeper. Attrs following such
+	// output are also printed on separate lines.
+	Indent string
 }
 
-func (t TextAttrs) Append(b []byte, _ context.Context, r slog.Record) []byte {
+func (t TextAttrs) Append(b []byte, c context.Context, r slog.Record) []byte {
+	kc := colorFor(c, t.KeyColor)
+	broken := false
 	r.Attrs(func(a slog.Attr) bool {
-		b = t.formatAttr(b, "", a)
+		if t.Indent != "" {
+			b = t.formatIndented(b, "", 0, a, kc, &broken)
+		} else {
+			b = t.formatAttr(b, "", a, kc)
+		}
 		return true
 	})
 	return b
 }
 
+// Attr is a [Formatter] that formats the value of the attribute with the given Key
+// in [slog.Record.Attrs]. Attributes in groups are addressed by dot-separated keys,
+// e.g. "req.id". Groups with empty keys are inlined. If several attributes match,
+// the first one is used. The value is resolved with [slog.Value.Resolve] and quoted
+// according to Quote. The result is empty if there is no such attribute, which can be
+// combined with [Conditional].
+type Attr struct {
+	Key   string
+	Quote QuoteType
+}
+
+func (at Attr) Append(b []byte, _ context.Context, r slog.Record) []byte {
+	v, found := recordAttr(r, at.Key)
+	if !found {
+		return b
+	}
+	return quote(b, v.String(), at.Quote)
+}
+
+// recordAttr returns the value of the first attr of r with the dot-separated key.
+func recordAttr(r slog.Record, key string) (v slog.Value, found bool) {
+	r.Attrs(func(a slog.Attr) bool {
+		v, found = findAttr(a, key)
+		return !found
+	})
+	return
+}
+
+// findAttr returns the value of the attr with the dot-separated key in a or its groups.
+func findAttr(a slog.Attr, key string) (slog.Value, bool) {
+	a.Value = a.Value.Resolve()
+	if a.Key == key && a.Key != "" {
+		return a.Value, true
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		return slog.Value{}, false
+	}
+	if a.Key != "" {
+		rest, ok := strings.CutPrefix(key, a.Key+".")
+		if !ok {
+			return slog.Value{}, false
+		}
+		key = rest
+	}
+	for _, aa := range a.Value.Group() {
+		if v, ok := findAttr(aa, key); ok {
+			return v, true
+		}
+	}
+	return slog.Value{}, false
+}
+
 // Conditional is a [Formatter] that wraps the output of the Inner formatter in the
 // [fmt.Sprintf]-like format only if Inner produces a non-empty string.
 // Internally this works by passing Inner to [fmt.Appendf] as a []byte slice.
@@ -143,11 +208,28 @@ func (co Conditional) Append(b []byte, c context.Context, r slog.Record) []byte
 	return b
 }
 
-func (t TextAttrs) formatAttr(b []byte, pfx string, a slog.Attr) []byte {
+func (t TextAttrs) formatAttr(b []byte, pfx string, a slog.Attr, kc Color) []byte {
+	a.Value = a.Value.Resolve()
+	if isEmptyAttr(a) {
+		return b
+	}
+	if len(t.Exclude) != 0 && a.Key != "" && slices.Contains(t.Exclude, pfx+a.Key) {
+		return b
+	}
 	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			pfx += a.Key + "."
+		}
 		for _, aa := range a.Value.Group() {
-			b = t.formatAttr(b, pfx+a.Key+".", aa)
+			b = t.formatAttr(b, pfx, aa, kc)
 		}
+	} else if kc != ColorNone {
+		b = append(b, ' ')
+		b = appendColorStart(b, kc)
+		b = fmt.Append(b, pfx, a.Key)
+		b = appendColorEnd(b)
+		b = append(b, '=')
+		b = quote(b, a.Value.String(), t.Quote)
 	} else {
 		b = fmt.Append(b, " ", pfx, a.Key, "=")
 		b = quote(b, a.Value.String(), t.Quote)
@@ -155,12 +237,112 @@ func (t TextAttrs) formatAttr(b []byte, pfx string, a slog.Attr) []byte {
 	return b
 }
 
+// formatIndented formats a at the given group depth in the Indent layout.
+// Broken is set once the output spans multiple lines.
+func (t TextAttrs) formatIndented(b []byte, pfx string, depth int, a slog.Attr, kc Color, broken *bool) []byte {
+	a.Value = a.Value.Resolve()
+	if isEmptyAttr(a) {
+		return b
+	}
+	if len(t.Exclude) != 0 && a.Key != "" && slices.Contains(t.Exclude, pfx+a.Key) {
+		return b
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key == "" {
+			for _, aa := range a.Value.Group() {
+				b = t.formatIndented(b, pfx, depth, aa, kc, broken)
+			}
+			return b
+		}
+		start, wasBroken := len(b), *broken
+		b = t.newLine(b, depth)
+		b = appendKey(b, a.Key, kc)
+		b = append(b, ':')
+		*broken = true
+		mark := len(b)
+		for _, aa := range a.Value.Group() {
+			b = t.formatIndented(b, pfx+a.Key+".", depth+1, aa, kc, broken)
+		}
+		if len(b) == mark {
+			// nothing to show in the group
+			b, *broken = b[:start], wasBroken
+		}
+		return b
+	}
+
+	if depth == 0 && !*broken {
+		b = append(b, ' ')
+	} else {
+		b = t.newLine(b, depth)
+	}
+	b = appendKey(b, a.Key, kc)
+	b = append(b, '=')
+	v := a.Value.String()
+	if !strings.Contains(v, "\n") {
+		return quote(b, v, t.Quote)
+	}
+	for _, line := range strings.Split(v, "\n") {
+		b = t.newLine(b, depth+1)
+		b = append(b, line...)
+	}
+	*broken = true
+	return b
+}
+
+// newLine starts a new line for an attr at the given group depth.
+func (t TextAttrs) newLine(b []byte, depth int) []byte {
+	b = append(b, '\n')
+	for i := 0; i <= depth; i++ {
+		b = append(b, t.Indent...)
+	}
+	return b
+}
+
+func appendKey(b []byte, key string, kc Color) []byte {
+	if kc == ColorNone {
+		return append(b, key...)
+	}
+	b = appendColorStart(b, kc)
+	b = append(b, key...)
+	return appendColorEnd(b)
+}
+
 // DefaultFormat returns a Formatter that mimics the default log format of slog.
 func DefaultFormat() Formatter {
 	return &defaultFormat
 }
 
+// ConsoleFormat returns a Layout for reading logs in a terminal during development.
+// It consists of a dimmed time of day with milliseconds, a three-letter level colored
+// by [DefaultLevelColors], the message padded so that attrs start in the same column,
+// the attrs in the multi-line [TextAttrs] layout with colored keys, and a [StackTrace].
+// Colors are only used if enabled by the sink, see [WriterSink].
+//
+// The result can be tweaked by replacing elements of Args.
+func ConsoleFormat() Layout {
+	return Layout{
+		Format: "%s %s %s%s%s",
+		Args: []Formatter{
+			Colorize{Inner: Time{Layout: "15:04:05.000"}, Color: ColorDim},
+			Colorize{
+				Inner:  Level{Names: &LevelNames{Exact: ExtendedLevelNames().Exact, Abbrev: AbbrevThree}},
+				Levels: DefaultLevelColors(),
+			},
+			Pad{Inner: Message{}, Width: 40},
+			TextAttrs{Quote: QuoteSmart, KeyColor: ColorCyan, Indent: "    "},
+			StackTrace{Indent: "    "},
+		},
+	}
+}
+
 func quote(b []byte, s string, q QuoteType) []byte {
+	switch q {
+	case QuoteJSON:
+		return appendJSONString(b, s)
+	case QuoteLogfmt:
+		return appendLogfmtString(b, s)
+	}
 	if needsQuoting(s, q) {
 		return strconv.AppendQuote(b, s)
 	} else {
@@ -24,6 +24,11 @@ func TestSource_Append(t *testing.T) {
 	assert.Contains(t, s, "/yall/util_test.go:14")
 }
 
+func TestTime_Append(t *testing.T) {
+	assert.Equal(t, "2020-11-22 12:34:56", formatToString(yall.Time{}, nil, rec()))
+	assert.Equal(t, "", formatToString(yall.Time{}, nil, slog.Record{}))
+}
+
 func TestTextAttrs_Append(t *testing.T) {
 	tests := []struct {
 		name string
@@ -64,17 +69,171 @@ func TestTextAttrs_Append(t *testing.T) {
 			quot: yall.QuoteSmart,
 			want: " a=\"\"",
 		},
+		{
+			name: "LogValuer",
+			rec:  rec("a", testLogValuer{slog.StringValue("b")}),
+			want: " a=b",
+		},
+		{
+			name: "LogValuerGroup",
+			rec:  rec("a", testLogValuer{slog.GroupValue(slog.Int("x", 1), slog.Any("y", testLogValuer{slog.IntValue(2)}))}),
+			want: " a.x=1 a.y=2",
+		},
+		{
+			name: "EmptyGroups",
+			rec:  rec(slog.Group("e"), "a", "b", slog.Group("g", slog.Group("h")), slog.Attr{}),
+			want: " a=b",
+		},
+		{
+			name: "InlineGroup",
+			rec:  rec(slog.Group("g", slog.Group("", "a", "b")), slog.Group("", "c", "d")),
+			want: " g.a=b c=d",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ta := yall.TextAttrs{tt.quot}
+			ta := yall.TextAttrs{Quote: tt.quot}
 			s := formatToString(&ta, nil, tt.rec)
 			assert.Equal(t, tt.want, s)
 		})
 	}
 }
 
+func TestTextAttrs_Exclude(t *testing.T) {
+	ta := yall.TextAttrs{Exclude: []string{"a", "g.x", "h"}}
+	r := rec("a", 1, "b", 2, slog.Group("g", "x", 3, "y", 4), slog.Group("h", "z", 5), slog.Group("", "a", 6))
+	assert.Equal(t, " b=2 g.y=4", formatToString(ta, nil, r))
+}
+
+func TestTextAttrs_Indent(t *testing.T) {
+	tests := []struct {
+		name string
+		rec  slog.Record
+		excl []string
+		want string
+	}{
+		{
+			name: "Flat",
+			rec:  rec("a", "b", "c", "d e"),
+			want: ` a=b c="d e"`,
+		},
+		{
+			name: "Group",
+			rec:  rec("a", 1, slog.Group("g", "x", 2, slog.Group("h", "y", 3)), "b", 4),
+			want: " a=1\n  g:\n    x=2\n    h:\n      y=3\n  b=4",
+		},
+		{
+			name: "Multiline",
+			rec:  rec("a", "line 1\nline 2", "b", 2),
+			want: " a=\n    line 1\n    line 2\n  b=2",
+		},
+		{
+			name: "EmptyGroup",
+			rec:  rec("a", 1, slog.Group("g", slog.Group("h")), "b", 2),
+			want: " a=1 b=2",
+		},
+		{
+			name: "Inline",
+			rec:  rec(slog.Group("", "a", 1), slog.Group("g", slog.Group("", "x", 2))),
+			want: " a=1\n  g:\n    x=2",
+		},
+		{
+			name: "Exclude",
+			rec:  rec(slog.Group("g", "x", 1, "y", 2), slog.Group("h", "z", 3)),
+			excl: []string{"g.x", "h.z"},
+			want: "\n  g:\n    y=2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := yall.TextAttrs{Quote: yall.QuoteSmart, Indent: "  ", Exclude: tt.excl}
+			s := formatToString(ta, nil, tt.rec)
+			assert.Equal(t, tt.want, s)
+		})
+	}
+}
+
+func TestConsoleFormat(t *testing.T) {
+	f := yall.ConsoleFormat()
+	r := rec("a", 1, slog.Group("g", "b", "c d"))
+	s := formatToString(f, yall.WithColor(someCtx, false), r)
+	assert.Equal(t, "12:34:56.000 INF msg                                      a=1\n    g:\n        b=\"c d\"", s)
+}
+
+func TestAttr_Append(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		quot yall.QuoteType
+		want string
+	}{
+		{
+			name: "TopLevel",
+			key:  "a",
+			want: "b c",
+		},
+		{
+			name: "Quoted",
+			key:  "a",
+			quot: yall.QuoteSmart,
+			want: `"b c"`,
+		},
+		{
+			name: "Group",
+			key:  "g.h.x",
+			want: "1",
+		},
+		{
+			name: "Inline",
+			key:  "i",
+			want: "2",
+		},
+		{
+			name: "DottedKey",
+			key:  "g.d.k",
+			want: "3",
+		},
+		{
+			name: "LogValuer",
+			key:  "lv.x",
+			want: "4",
+		},
+		{
+			name: "FirstMatch",
+			key:  "dup",
+			want: "5",
+		},
+		{
+			name: "Missing",
+			key:  "g.nope",
+			want: "",
+		},
+		{
+			name: "Empty",
+			key:  "",
+			want: "",
+		},
+	}
+
+	r := rec(
+		"a", "b c",
+		slog.Group("g", slog.Group("h", "x", 1), "d.k", 3),
+		slog.Group("", "i", 2),
+		"lv", testLogValuer{slog.GroupValue(slog.Int("x", 4))},
+		"dup", 5,
+		"dup", 6,
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := formatToString(yall.Attr{Key: tt.key, Quote: tt.quot}, nil, r)
+			assert.Equal(t, tt.want, s)
+		})
+	}
+}
+
 func TestConditional_Append(t *testing.T) {
 	tests := []struct {
 		name   string
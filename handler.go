@@ -3,58 +3,209 @@ package yall
 import (
 	"context"
 	"log/slog"
+	"runtime"
+	"slices"
+	"time"
 )
 
+// HandlerOptions are options for a handler created by [NewHandlerWithOptions].
+type HandlerOptions struct {
+	// ReplaceAttr is called to rewrite each non-group attribute before the record is sent
+	// to the Sink, with the same semantics as [slog.HandlerOptions.ReplaceAttr].
+	//
+	// The groups argument lists the groups the attribute is nested in: the groups opened
+	// with [slog.Logger.WithGroup] before the attribute was added, followed by the keys of
+	// the group attributes containing it. The attribute value is resolved before the call.
+	// If ReplaceAttr returns the zero Attr, the attribute is removed. Attributes added
+	// with [slog.Logger.With] are rewritten once, when they are added.
+	//
+	// ReplaceAttr is also called for the built-in attributes with keys [slog.TimeKey],
+	// [slog.LevelKey], [slog.SourceKey], and [slog.MessageKey], and nil groups.
+	// Their values are [time.Time], [slog.Level], *[slog.Source], and string, respectively.
+	// Since these are stored in [slog.Record] fields which have no keys, only the returned
+	// value is used: it replaces the time, level, or message, and a zero Attr removes the
+	// time, source, or message. The time is not passed if it is zero, and the source
+	// is not passed if [slog.Record.PC] is zero. Keys of the built-in attributes
+	// are chosen by formatters, e.g. [JSON.MessageKey]. A level may be returned
+	// as a [slog.Leveler] or as a string parsed by [slog.Level.UnmarshalText], such as
+	// "WARN" or "info+2"; other values leave the level unchanged.
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+
+	// SlogOrder makes the handler order attributes like the standard slog handlers do:
+	// at each group level, attributes added with [slog.Logger.With] come first, in the order
+	// they were added, followed by the attributes of the log call or the nested group.
+	// By default, the attributes of the log call come first, followed by the With attributes
+	// in the reverse order.
+	SlogOrder bool
+
+	// ContextAttrs extracts attributes from the context passed to Handle. The attributes
+	// are added to the record at the top level, outside of any groups, after all other
+	// attributes, or before them with SlogOrder. They are rewritten with ReplaceAttr.
+	// The default is [ContextAttrs], which returns the attributes added
+	// with [WithContextAttrs].
+	ContextAttrs func(ctx context.Context) []slog.Attr
+
+	// StackLevel enables capturing the stack trace of the logging goroutine for records
+	// with this or a higher level. The stack trace starts at the logging call and is passed
+	// to the Sink in the context, see [ContextStack]. It is formatted by [StackTrace]
+	// and [JSON]. Stack traces are not captured if StackLevel is nil.
+	StackLevel slog.Leveler
+}
+
 // NewHandler creates an implementation of slog.Handler that sends logging events to a Sink.
 //
 // The handler takes care of [slog.Handler.WithAttrs] and [slog.Handler.WithGroup] and always
 // sends a complete slog.Record to the Sink. The Sink still needs to resolve and handle the attrs.
+//
+// The handler and all handlers derived from it implement [Flusher] and [Closer] by flushing
+// and closing the Sink.
 func NewHandler(sink Sink) slog.Handler {
-	return &sinkHandler{sink: sink}
+	return NewHandlerWithOptions(sink, nil)
 }
 
-type sinkHandler struct {
+// NewHandlerWithOptions is like [NewHandler] but uses the given options.
+// If opts is nil, the default options are used.
+func NewHandlerWithOptions(sink Sink, opts *HandlerOptions) slog.Handler {
+	h := &handler{sink: sink, opts: &HandlerOptions{}, attrs: [][]slog.Attr{nil}}
+	if opts != nil {
+		*h.opts = *opts
+	}
+	return h
+}
+
+// handler is the slog.Handler returned by NewHandler and its WithAttrs and WithGroup methods.
+// It keeps everything added with WithAttrs and WithGroup ready to use, so that Handle
+// assembles a record with a single allocation regardless of the nesting depth.
+type handler struct {
 	sink Sink
+	opts *HandlerOptions
+	// groups are the names of the open groups.
+	groups []string
+	// attrs[i] are the attrs added at group depth i, in the order they appear in a record.
+	// len(attrs) is len(groups)+1.
+	attrs [][]slog.Attr
+	// size is the total number of attrs in attrs plus the number of groups.
+	size int
 }
 
-func (h *sinkHandler) Enabled(ctx context.Context, level slog.Level) bool {
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
 	return h.sink.Enabled(ctx, level)
 }
 
-func (h *sinkHandler) Handle(ctx context.Context, record slog.Record) error {
-	return h.sink.Handle(ctx, record)
-}
+func (h *handler) Handle(ctx context.Context, record slog.Record) error {
+	if h.opts.StackLevel != nil && record.Level >= h.opts.StackLevel.Level() {
+		ctx = WithStack(ctx, callerStack(record.PC))
+	}
+	record = h.opts.replaceRecord(h.groups, record)
+	ctxAttrs := h.opts.contextAttrs(ctx)
+	if h.size == 0 && len(ctxAttrs) == 0 {
+		return h.sink.Handle(ctx, record)
+	}
 
-func (h *sinkHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return withAttrs(h, attrs)
-}
+	depth := len(h.groups)
+	if record.NumAttrs() == 0 {
+		// slog omits groups without attrs
+		for depth > 0 && len(h.attrs[depth]) == 0 {
+			depth--
+		}
+	}
+	if depth == 0 && !h.opts.SlogOrder {
+		record.AddAttrs(h.attrs[0]...)
+		record.AddAttrs(ctxAttrs...)
+		return h.sink.Handle(ctx, record)
+	}
 
-func (h *sinkHandler) WithGroup(name string) slog.Handler {
-	return withGroup(h, name)
+	// The contents of all groups share one buffer, innermost first:
+	// [record attrs, attrs[depth]], [group depth, attrs[depth-1]], ..., [group 1, attrs[0]],
+	// or with SlogOrder, [attrs[depth], record attrs], [attrs[depth-1], group depth], ...
+	buf := make([]slog.Attr, 0, record.NumAttrs()+h.size+len(ctxAttrs))
+	if h.opts.SlogOrder {
+		buf = h.appendLevel(buf, depth, ctxAttrs)
+	}
+	record.Attrs(func(a slog.Attr) bool {
+		buf = append(buf, a)
+		return true
+	})
+	start := 0
+	for i := depth; i > 0; i-- {
+		if !h.opts.SlogOrder {
+			buf = h.appendLevel(buf, i, ctxAttrs)
+		}
+		g := slog.Attr{Key: h.groups[i-1], Value: slog.GroupValue(buf[start:]...)}
+		start = len(buf)
+		if h.opts.SlogOrder {
+			buf = h.appendLevel(buf, i-1, ctxAttrs)
+		}
+		buf = append(buf, g)
+	}
+	if !h.opts.SlogOrder {
+		buf = h.appendLevel(buf, 0, ctxAttrs)
+	}
+
+	r := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	r.AddAttrs(buf[start:]...)
+	return h.sink.Handle(ctx, r)
 }
 
-type attrsHandler struct {
-	next  slog.Handler
-	attrs []slog.Attr
+// appendLevel appends the attrs of group depth i to buf. The top level also includes ctxAttrs.
+func (h *handler) appendLevel(buf []slog.Attr, i int, ctxAttrs []slog.Attr) []slog.Attr {
+	if i != 0 {
+		return append(buf, h.attrs[i]...)
+	}
+	if h.opts.SlogOrder {
+		buf = append(buf, ctxAttrs...)
+		return append(buf, h.attrs[0]...)
+	}
+	buf = append(buf, h.attrs[0]...)
+	return append(buf, ctxAttrs...)
 }
 
-func withAttrs(next slog.Handler, attrs []slog.Attr) slog.Handler {
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	attrs = h.opts.replaceAttrs(h.groups, attrs)
 	empty := countEmptyGroups(attrs)
 	if empty == len(attrs) {
 		// attrs is empty or consists exclusively of empty groups
-		return next
-	}
-	if empty != 0 {
-		// only leave non-empty attrs
-		aa := make([]slog.Attr, 0, len(attrs)-empty)
-		for _, a := range attrs {
-			if !isEmptyGroup(a) {
-				aa = append(aa, a)
-			}
+		return h
+	}
+
+	depth := len(h.groups)
+	level := make([]slog.Attr, 0, len(attrs)-empty+len(h.attrs[depth]))
+	if h.opts.SlogOrder {
+		level = append(level, h.attrs[depth]...)
+	}
+	for _, a := range attrs {
+		if !isEmptyGroup(a) {
+			level = append(level, a)
 		}
-		attrs = aa
 	}
-	return &attrsHandler{next: next, attrs: attrs}
+	if !h.opts.SlogOrder {
+		level = append(level, h.attrs[depth]...)
+	}
+
+	h2 := *h
+	h2.attrs = slices.Clone(h.attrs)
+	h2.attrs[depth] = level
+	h2.size += len(attrs) - empty
+	return &h2
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(slices.Clip(h.groups), name)
+	h2.attrs = append(slices.Clip(h.attrs), nil)
+	h2.size++
+	return &h2
+}
+
+func (h *handler) Flush(ctx context.Context) error {
+	return Flush(ctx, h.sink)
+}
+
+func (h *handler) Close(ctx context.Context) error {
+	return Close(ctx, h.sink)
 }
 
 func countEmptyGroups(attrs []slog.Attr) (count int) {
@@ -74,54 +225,122 @@ func isEmptyGroup(attr slog.Attr) bool {
 	return len(g) == countEmptyGroups(g)
 }
 
-func (h *attrsHandler) Enabled(ctx context.Context, level slog.Level) bool {
-	return h.next.Enabled(ctx, level)
+// replacedLevel returns the level held by a built-in level attribute returned by ReplaceAttr.
+func replacedLevel(a slog.Attr) (slog.Level, bool) {
+	switch a.Value.Kind() {
+	case slog.KindAny:
+		if l, ok := a.Value.Any().(slog.Leveler); ok {
+			return l.Level(), true
+		}
+	case slog.KindString:
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(a.Value.String())); err == nil {
+			return l, true
+		}
+	}
+	return 0, false
 }
 
-func (h *attrsHandler) Handle(ctx context.Context, record slog.Record) error {
-	record.AddAttrs(h.attrs...)
-	return h.next.Handle(ctx, record)
-}
+// replaceRecord applies ReplaceAttr to the built-in attributes and the attributes of r,
+// which are nested in groups. It returns r if there is nothing to replace.
+func (o *HandlerOptions) replaceRecord(groups []string, r slog.Record) slog.Record {
+	if o.ReplaceAttr == nil {
+		return r
+	}
 
-func (h *attrsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return withAttrs(h, attrs)
-}
+	if !r.Time.IsZero() {
+		a := o.replaceBuiltIn(slog.Time(slog.TimeKey, r.Time))
+		if isEmptyAttr(a) {
+			r.Time = time.Time{}
+		} else if a.Value.Kind() == slog.KindTime {
+			r.Time = a.Value.Time()
+		}
+	}
+	if l, ok := replacedLevel(o.replaceBuiltIn(slog.Any(slog.LevelKey, r.Level))); ok {
+		r.Level = l
+	}
+	if r.PC != 0 {
+		if a := o.replaceBuiltIn(slog.Any(slog.SourceKey, recordSource(r.PC))); isEmptyAttr(a) {
+			r.PC = 0
+		}
+	}
+	if a := o.replaceBuiltIn(slog.String(slog.MessageKey, r.Message)); isEmptyAttr(a) {
+		r.Message = ""
+	} else {
+		r.Message = a.Value.String()
+	}
 
-func (h *attrsHandler) WithGroup(name string) slog.Handler {
-	return withGroup(h, name)
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		if a = o.replaceAttr(groups, a); !isEmptyAttr(a) {
+			nr.AddAttrs(a)
+		}
+		return true
+	})
+	return nr
 }
 
-type groupHandler struct {
-	next slog.Handler
-	name string
+// contextAttrs extracts the attributes from ctx and applies ReplaceAttr to them.
+func (o *HandlerOptions) contextAttrs(ctx context.Context) []slog.Attr {
+	extract := o.ContextAttrs
+	if extract == nil {
+		extract = ContextAttrs
+	}
+	if ctx == nil {
+		return nil
+	}
+	return o.replaceAttrs(nil, extract(ctx))
 }
 
-func withGroup(next slog.Handler, name string) slog.Handler {
-	if name == "" {
-		return next
-	}
-	return &groupHandler{next: next, name: name}
+func (o *HandlerOptions) replaceBuiltIn(a slog.Attr) slog.Attr {
+	a = o.ReplaceAttr(nil, a)
+	a.Value = a.Value.Resolve()
+	return a
 }
 
-func (h *groupHandler) Enabled(ctx context.Context, level slog.Level) bool {
-	return h.next.Enabled(ctx, level)
+// replaceAttrs applies ReplaceAttr to attrs nested in groups and returns the non-empty results.
+// It returns attrs if there is nothing to replace.
+func (o *HandlerOptions) replaceAttrs(groups []string, attrs []slog.Attr) []slog.Attr {
+	if o.ReplaceAttr == nil {
+		return attrs
+	}
+	out := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		if a = o.replaceAttr(groups, a); !isEmptyAttr(a) {
+			out = append(out, a)
+		}
+	}
+	return out
 }
 
-func (h *groupHandler) Handle(ctx context.Context, record slog.Record) error {
-	attrs := make([]slog.Attr, 0, record.NumAttrs())
-	record.Attrs(func(a slog.Attr) bool {
-		attrs = append(attrs, a)
-		return true
-	})
-	r := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
-	r.AddAttrs(slog.Attr{Key: h.name, Value: slog.GroupValue(attrs...)})
-	return h.next.Handle(ctx, r)
+func (o *HandlerOptions) replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(o.replaceAttrs(groups, a.Value.Group())...)}
+	}
+	a = o.ReplaceAttr(groups, a)
+	a.Value = a.Value.Resolve()
+	return a
 }
 
-func (h *groupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return withAttrs(h, attrs)
+// callerStack returns the stack of the calling goroutine starting at the frame of pc.
+// If the frame is not found, the stack starts at the caller of Handle.
+func callerStack(pc uintptr) Stack {
+	s := CaptureStack(2)
+	for i, p := range s {
+		if p == pc {
+			return s[i:]
+		}
+	}
+	return s
 }
 
-func (h *groupHandler) WithGroup(name string) slog.Handler {
-	return withGroup(h, name)
+// recordSource returns the source location of pc.
+func recordSource(pc uintptr) *slog.Source {
+	fs := runtime.CallersFrames([]uintptr{pc})
+	f, _ := fs.Next()
+	return &slog.Source{Function: f.Function, File: f.File, Line: f.Line}
 }
@@ -1,11 +1,15 @@
 package yall_test
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"log/slog"
 	"github.com/snake-scaly/yall"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestHandler(t *testing.T) {
@@ -112,6 +116,13 @@ func TestHandler(t *testing.T) {
 	}
 }
 
+func TestHandler_WithGroupNoAttrs(t *testing.T) {
+	s := &testSink{enabled: true}
+	h := yall.NewHandler(s).WithGroup("g").WithGroup("h")
+	assert.Nil(t, h.Handle(someCtx, rec()))
+	assert.Equal(t, rec(), s.calls[0].record)
+}
+
 func TestHandler_Enabled(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -152,6 +163,275 @@ func TestHandler_Enabled(t *testing.T) {
 	}
 }
 
+func TestHandler_ReplaceAttrLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		value slog.Value
+		want  slog.Level
+	}{
+		{name: "Leveler", value: slog.AnyValue(slog.LevelWarn), want: slog.LevelWarn},
+		{name: "String", value: slog.StringValue("error"), want: slog.LevelError},
+		{name: "StringOffset", value: slog.StringValue("INFO+2"), want: slog.LevelInfo + 2},
+		{name: "InvalidString", value: slog.StringValue("bogus"), want: slog.LevelInfo},
+		{name: "OtherKind", value: slog.IntValue(8), want: slog.LevelInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &testSink{enabled: true}
+			h := yall.NewHandlerWithOptions(s, &yall.HandlerOptions{
+				ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.LevelKey {
+						a.Value = tt.value
+					}
+					return a
+				},
+			})
+			assert.Nil(t, h.Handle(someCtx, rec()))
+			assert.Equal(t, 1, len(s.calls))
+			assert.Equal(t, tt.want, s.calls[0].record.Level)
+		})
+	}
+}
+
+func TestHandler_ReplaceAttr(t *testing.T) {
+	redact := func(groups []string, a slog.Attr) slog.Attr {
+		switch {
+		case a.Key == "secret":
+			return slog.String(a.Key, "***")
+		case a.Key == "drop":
+			return slog.Attr{}
+		case a.Key == "n" && len(groups) != 0:
+			return slog.String(a.Key, strings.Join(groups, "."))
+		case a.Key == slog.MessageKey:
+			return slog.String(a.Key, "<"+a.Value.String()+">")
+		case a.Key == slog.LevelKey:
+			return slog.Any(a.Key, slog.LevelWarn)
+		case a.Key == slog.TimeKey:
+			return slog.Attr{}
+		}
+		return a
+	}
+
+	tests := []struct {
+		name string
+		with func(slog.Handler) slog.Handler
+		want string
+	}{
+		{
+			name: "Direct",
+			with: func(h slog.Handler) slog.Handler {
+				return h
+			},
+			want: `{"level":"WARN","msg":"<msg>","n":"v","secret":"***"}`,
+		},
+		{
+			name: "WithAttrs",
+			with: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.String("secret", "x"), slog.Int("drop", 1)})
+			},
+			want: `{"level":"WARN","msg":"<msg>","n":"v","secret":"***","secret":"***"}`,
+		},
+		{
+			name: "WithGroup",
+			with: func(h slog.Handler) slog.Handler {
+				h = h.WithGroup("g").WithAttrs([]slog.Attr{slog.String("n", "w")})
+				return h.WithGroup("h")
+			},
+			want: `{"level":"WARN","msg":"<msg>","g":{"h":{"n":"g.h","secret":"***"},"n":"g"}}`,
+		},
+		{
+			name: "NestedGroup",
+			with: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.Group("a", slog.Group("b", "n", 1, "drop", 2))})
+			},
+			want: `{"level":"WARN","msg":"<msg>","n":"v","secret":"***","a":{"b":{"n":"a.b"}}}`,
+		},
+		{
+			name: "DroppedGroup",
+			with: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.Group("a", "drop", 1)})
+			},
+			want: `{"level":"WARN","msg":"<msg>","n":"v","secret":"***"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &testSink{enabled: true}
+			h := yall.NewHandlerWithOptions(s, &yall.HandlerOptions{ReplaceAttr: redact})
+			h = tt.with(h)
+			e := h.Handle(someCtx, rec("n", "v", "secret", "s", "drop", 0))
+			assert.Nil(t, e)
+			assert.Equal(t, 1, len(s.calls))
+			assert.Equal(t, tt.want, formatToString(yall.JSON{}, nil, s.calls[0].record))
+		})
+	}
+}
+
+func TestHandler_ReplaceAttrSource(t *testing.T) {
+	var source *slog.Source
+	s := &testSink{enabled: true}
+	h := yall.NewHandlerWithOptions(s, &yall.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.SourceKey {
+				source = a.Value.Any().(*slog.Source)
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+
+	assert.Nil(t, h.Handle(someCtx, rec()))
+	assert.Equal(t, "github.com/snake-scaly/yall_test.rec", source.Function)
+	assert.Zero(t, s.calls[0].record.PC)
+}
+
+func TestHandler_SlogOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		with func(*slog.Logger) *slog.Logger
+	}{
+		{
+			name: "Direct",
+			with: func(l *slog.Logger) *slog.Logger {
+				return l
+			},
+		},
+		{
+			name: "WithAttrs",
+			with: func(l *slog.Logger) *slog.Logger {
+				return l.With("x", 1).With("y", 2)
+			},
+		},
+		{
+			name: "WithGroup",
+			with: func(l *slog.Logger) *slog.Logger {
+				return l.With("outer", 1).WithGroup("inner").With("x", 2).With("y", 3)
+			},
+		},
+		{
+			name: "Nested",
+			with: func(l *slog.Logger) *slog.Logger {
+				return l.WithGroup("g").With("a", 1).WithGroup("h").With("b", 2).WithGroup("i")
+			},
+		},
+	}
+
+	format := yall.Layout{
+		Format: "level=%s msg=%s%s",
+		Args:   []yall.Formatter{yall.Level{}, yall.Message{}, yall.TextAttrs{}},
+	}
+	noTime := func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey && len(groups) == 0 {
+			return slog.Attr{}
+		}
+		return a
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, args := range [][]any{{"n", "v", slog.Group("c", "d", 4)}, nil} {
+				want := bytes.Buffer{}
+				tt.with(slog.New(slog.NewTextHandler(&want, &slog.HandlerOptions{ReplaceAttr: noTime}))).Info("msg", args...)
+
+				got := bytes.Buffer{}
+				s := &yall.WriterSink{Writer: &got, Level: slog.LevelInfo, Format: format}
+				tt.with(slog.New(yall.NewHandlerWithOptions(s, &yall.HandlerOptions{SlogOrder: true}))).Info("msg", args...)
+
+				assert.Equal(t, want.String(), got.String())
+			}
+		})
+	}
+}
+
+func TestHandler_ContextAttrs(t *testing.T) {
+	ctx := yall.WithContextAttrs(someCtx, "req", 7)
+	tests := []struct {
+		name string
+		opts yall.HandlerOptions
+		want string
+	}{
+		{
+			name: "Default",
+			want: `{"level":"INFO","msg":"msg","g":{"n":"v","b":2},"a":1,"req":7}`,
+		},
+		{
+			name: "SlogOrder",
+			opts: yall.HandlerOptions{SlogOrder: true},
+			want: `{"level":"INFO","msg":"msg","req":7,"a":1,"g":{"b":2,"n":"v"}}`,
+		},
+		{
+			name: "Extractor",
+			opts: yall.HandlerOptions{ContextAttrs: func(c context.Context) []slog.Attr {
+				return []slog.Attr{slog.Int("ex", len(yall.ContextAttrs(c)))}
+			}},
+			want: `{"level":"INFO","msg":"msg","g":{"n":"v","b":2},"a":1,"ex":1}`,
+		},
+		{
+			name: "ReplaceAttr",
+			opts: yall.HandlerOptions{ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == "req" {
+					return slog.String(a.Key, "redacted")
+				}
+				return a
+			}},
+			want: `{"level":"INFO","msg":"msg","g":{"n":"v","b":2},"a":1,"req":"redacted"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &testSink{enabled: true}
+			h := yall.NewHandlerWithOptions(s, &tt.opts)
+			h = h.WithAttrs([]slog.Attr{slog.Int("a", 1)}).WithGroup("g").WithAttrs([]slog.Attr{slog.Int("b", 2)})
+			r := rec("n", "v")
+			r.Time = time.Time{}
+			assert.Nil(t, h.Handle(ctx, r))
+			assert.Equal(t, tt.want, formatToString(yall.JSON{}, nil, s.calls[0].record))
+		})
+	}
+}
+
+func TestHandler_FlushClose(t *testing.T) {
+	s := &lifecycleSink{}
+	h := yall.NewHandler(s)
+	h = h.WithAttrs([]slog.Attr{slog.String("a", "b")}).WithGroup("g")
+	logger := slog.New(h)
+
+	assert.Nil(t, yall.Flush(someCtx, logger.Handler()))
+	assert.Nil(t, yall.Close(someCtx, logger.Handler()))
+	assert.Equal(t, 1, s.flushes)
+	assert.Equal(t, 1, s.closes)
+}
+
+func BenchmarkHandler(b *testing.B) {
+	for depth := 1; depth <= 10; depth++ {
+		b.Run(fmt.Sprintf("Depth%d", depth), func(b *testing.B) {
+			h := yall.NewHandler(nopSink{})
+			for i := 0; i < depth; i++ {
+				h = h.WithAttrs([]slog.Attr{slog.Int("a", i)}).WithGroup("g")
+			}
+			r := rec("n", "v")
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = h.Handle(someCtx, r)
+			}
+		})
+	}
+}
+
+type nopSink struct{}
+
+func (nopSink) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (nopSink) Handle(context.Context, slog.Record) error {
+	return nil
+}
+
 type testSink struct {
 	enabled bool
 	err     error
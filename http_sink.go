@@ -0,0 +1,392 @@
+package yall
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPSinkOptions configures an [HTTPSink].
+type HTTPSinkOptions struct {
+	// Level is the minimum level of records. The default is [slog.LevelInfo].
+	Level slog.Leveler
+
+	// Format formats each record. The default is [JSON].
+	Format Formatter
+
+	// Envelope appends the request body for a batch of formatted records to b.
+	// The default is [NDJSON].
+	Envelope func(b []byte, records [][]byte) []byte
+
+	// ContentType is the Content-Type of requests. The default is "application/x-ndjson".
+	ContentType string
+
+	// Header contains additional request headers, e.g. for authorization.
+	Header http.Header
+
+	// Client sends the requests. The default is a client with a 30 second timeout.
+	Client *http.Client
+
+	// A batch is sent when it has MaxCount records, when it reaches MaxBytes bytes
+	// of formatted records, or MaxLatency after its first record was added, whichever
+	// happens first. The defaults are 100 records, 1 MiB, and 1 second.
+	MaxCount   int
+	MaxBytes   int
+	MaxLatency time.Duration
+
+	// MaxPending is the maximum number of batches waiting to be sent. When there are more,
+	// the oldest batch is dropped. The default is 10.
+	MaxPending int
+
+	// Uncompressed disables gzip compression of request bodies.
+	Uncompressed bool
+
+	// MaxRetries is the number of times a batch is sent again after a network error,
+	// a 429 Too Many Requests, or a 5xx response. The default is 5. Negative values
+	// disable retries.
+	MaxRetries int
+
+	// MinBackoff and MaxBackoff bound the delay before a retry, which doubles with every
+	// attempt and is randomized by up to a half. A Retry-After response header overrides
+	// the delay, however long it is; Close with a done context cancels the wait.
+	// The defaults are 500 milliseconds and 30 seconds.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// OnError is called on the background goroutine with errors of batches which could not
+	// be sent. Errors are discarded if OnError is nil.
+	OnError func(error)
+}
+
+var errHTTPSinkClosed = errors.New("yall: http sink is closed")
+
+var (
+	_ Sink    = (*HTTPSink)(nil)
+	_ Flusher = (*HTTPSink)(nil)
+	_ Closer  = (*HTTPSink)(nil)
+)
+
+// HTTPSink is a sink that sends records in batches to an HTTP endpoint with POST requests,
+// such as a log ingestion API. Records are formatted when they are handled, collected
+// into batches, and sent on a background goroutine. Handle never blocks on the network.
+//
+// The request body is built by the Envelope option, newline-delimited records by default.
+// Together with a suitable Format this covers most ingestion APIs.
+//
+// Create instances with [NewHTTPSink]. Call Close to send the remaining records and stop
+// the background goroutine.
+type HTTPSink struct {
+	url     string
+	opts    HTTPSinkOptions
+	dropped atomic.Uint64
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	lock      sync.Mutex
+	batch     [][]byte
+	batchSize int
+	batchGen  uint64 // incremented whenever a batch is shipped
+	timer     *time.Timer
+	queue     [][][]byte
+	accepted  uint64 // batches shipped or dropped so far
+	finished  uint64 // batches sent, failed or dropped so far
+	closed    bool
+	progress  chan struct{} // closed when finished changes
+	wake      chan struct{}
+	stopped   chan struct{}
+}
+
+// NewHTTPSink creates an HTTPSink posting to url and starts its background goroutine.
+// If opts is nil, the default options are used.
+func NewHTTPSink(url string, opts *HTTPSinkOptions) *HTTPSink {
+	s := &HTTPSink{
+		url:     url,
+		wake:    make(chan struct{}, 1),
+		stopped: make(chan struct{}),
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.Level == nil {
+		s.opts.Level = slog.LevelInfo
+	}
+	if s.opts.Format == nil {
+		s.opts.Format = JSON{}
+	}
+	if s.opts.Envelope == nil {
+		s.opts.Envelope = NDJSON
+	}
+	if s.opts.ContentType == "" {
+		s.opts.ContentType = "application/x-ndjson"
+	}
+	if s.opts.Client == nil {
+		s.opts.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if s.opts.MaxCount <= 0 {
+		s.opts.MaxCount = 100
+	}
+	if s.opts.MaxBytes <= 0 {
+		s.opts.MaxBytes = 1 << 20
+	}
+	if s.opts.MaxLatency <= 0 {
+		s.opts.MaxLatency = time.Second
+	}
+	if s.opts.MaxPending <= 0 {
+		s.opts.MaxPending = 10
+	}
+	if s.opts.MaxRetries == 0 {
+		s.opts.MaxRetries = 5
+	}
+	if s.opts.MinBackoff <= 0 {
+		s.opts.MinBackoff = 500 * time.Millisecond
+	}
+	if s.opts.MaxBackoff <= 0 {
+		s.opts.MaxBackoff = 30 * time.Second
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	go s.run()
+	return s
+}
+
+// NDJSON is the default envelope of [HTTPSink]. It terminates each record with a new line.
+func NDJSON(b []byte, records [][]byte) []byte {
+	for _, r := range records {
+		b = append(b, r...)
+		b = append(b, '\n')
+	}
+	return b
+}
+
+// Dropped returns the number of records dropped due to too many pending batches.
+func (s *HTTPSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *HTTPSink) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= s.opts.Level.Level()
+}
+
+// Handle adds the record to the current batch. Dropping a record is not an error.
+func (s *HTTPSink) Handle(c context.Context, r slog.Record) error {
+	rec := s.opts.Format.Append(nil, c, r)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return errHTTPSinkClosed
+	}
+	if len(s.batch) != 0 && s.batchSize+len(rec) > s.opts.MaxBytes {
+		s.shipLocked()
+	}
+	s.batch = append(s.batch, rec)
+	s.batchSize += len(rec)
+	if len(s.batch) >= s.opts.MaxCount || s.batchSize >= s.opts.MaxBytes {
+		s.shipLocked()
+	} else if len(s.batch) == 1 {
+		gen := s.batchGen
+		s.timer = time.AfterFunc(s.opts.MaxLatency, func() {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			if s.batchGen == gen {
+				s.shipLocked()
+			}
+		})
+	}
+	return nil
+}
+
+// Flush sends the current batch and waits until all batches are sent or fail.
+// It returns ctx.Err() if ctx is done first.
+func (s *HTTPSink) Flush(ctx context.Context) error {
+	s.lock.Lock()
+	s.shipLocked()
+	target := s.accepted
+	for s.finished < target {
+		progress := broadcastChan(&s.progress)
+		s.lock.Unlock()
+		select {
+		case <-progress:
+		case <-s.stopped:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		s.lock.Lock()
+	}
+	s.lock.Unlock()
+	return nil
+}
+
+// Close stops accepting records, sends the remaining batches, and waits until the background
+// goroutine exits. If ctx is done first, Close cancels the requests in progress, so that
+// the remaining batches fail without retries, and returns ctx.Err().
+func (s *HTTPSink) Close(ctx context.Context) error {
+	s.lock.Lock()
+	s.shipLocked()
+	s.closed = true
+	s.lock.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	select {
+	case <-s.stopped:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
+
+// shipLocked moves the current batch to the queue of the background goroutine.
+func (s *HTTPSink) shipLocked() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if len(s.batch) == 0 {
+		return
+	}
+	s.queue = append(s.queue, s.batch)
+	s.batch = nil
+	s.batchSize = 0
+	s.batchGen++
+	s.accepted++
+	for len(s.queue) > s.opts.MaxPending {
+		s.dropped.Add(uint64(len(s.queue[0])))
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		s.finished++
+		notifyChan(&s.progress)
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *HTTPSink) run() {
+	defer close(s.stopped)
+	for {
+		s.lock.Lock()
+		if len(s.queue) == 0 {
+			closed := s.closed
+			s.lock.Unlock()
+			if closed {
+				return
+			}
+			<-s.wake
+			continue
+		}
+		batch := s.queue[0]
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		s.lock.Unlock()
+
+		if err := s.post(batch); err != nil && s.opts.OnError != nil {
+			s.opts.OnError(err)
+		}
+
+		s.lock.Lock()
+		s.finished++
+		notifyChan(&s.progress)
+		s.lock.Unlock()
+	}
+}
+
+// post sends a batch, retrying on temporary failures.
+func (s *HTTPSink) post(batch [][]byte) error {
+	body := s.opts.Envelope(nil, batch)
+	if !s.opts.Uncompressed {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write(body)
+		_ = zw.Close()
+		body = buf.Bytes()
+	}
+
+	backoff := s.opts.MinBackoff
+	for attempt := 0; ; attempt++ {
+		delay, err := s.postOnce(body)
+		if err == nil {
+			return nil
+		}
+		if delay < 0 || attempt >= s.opts.MaxRetries {
+			return err
+		}
+		if delay == 0 {
+			delay = backoff/2 + rand.N(backoff/2+1)
+			backoff = min(backoff*2, s.opts.MaxBackoff)
+		}
+		select {
+		case <-time.After(delay):
+		case <-s.ctx.Done():
+			return errors.Join(err, s.ctx.Err())
+		}
+	}
+}
+
+// postOnce sends a request. On failure it returns the delay requested by the server,
+// zero to use the backoff, or a negative delay if the request must not be retried.
+func (s *HTTPSink) postOnce(body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+	for k, v := range s.opts.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", s.opts.ContentType)
+	if !s.opts.Uncompressed {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, nil
+	}
+
+	err = fmt.Errorf("yall: http sink: %s", resp.Status)
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return -1, err
+	}
+	if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+		// A zero delay means using the backoff, so retry as soon as possible instead.
+		return max(d, time.Nanosecond), err
+	}
+	return 0, err
+}
+
+// retryAfter parses the value of a Retry-After header, either a number of seconds or a date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
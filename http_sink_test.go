@@ -0,0 +1,311 @@
+package yall_test
+
+import (
+	"compress/gzip"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"github.com/snake-scaly/yall"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPSink_Batching(t *testing.T) {
+	tests := []struct {
+		name string
+		opts yall.HTTPSinkOptions
+		msgs []string
+		want []string
+	}{
+		{
+			name: "Count",
+			opts: yall.HTTPSinkOptions{MaxCount: 2},
+			msgs: []string{"a", "b", "c", "d", "e"},
+			want: []string{"a\nb\n", "c\nd\n", "e\n"},
+		},
+		{
+			name: "Bytes",
+			opts: yall.HTTPSinkOptions{MaxBytes: 5},
+			msgs: []string{"ab", "cd", "efg", "hijklm", "n"},
+			want: []string{"ab\ncd\n", "efg\n", "hijklm\n", "n\n"},
+		},
+		{
+			name: "Envelope",
+			opts: yall.HTTPSinkOptions{
+				MaxCount: 2,
+				Envelope: func(b []byte, records [][]byte) []byte {
+					b = append(b, '[')
+					for i, r := range records {
+						if i != 0 {
+							b = append(b, ',')
+						}
+						b = append(b, r...)
+					}
+					return append(b, ']')
+				},
+			},
+			msgs: []string{"a", "b", "c"},
+			want: []string{"[a,b]", "[c]"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newHTTPRecorder(t)
+			opts := tt.opts
+			opts.Format = yall.Message{}
+			opts.MaxLatency = time.Hour
+			s := yall.NewHTTPSink(srv.URL, &opts)
+			for _, m := range tt.msgs {
+				assert.Nil(t, s.Handle(someCtx, msgRec(someTime, m)))
+			}
+			assert.Nil(t, s.Close(someCtx))
+			assert.Equal(t, tt.want, srv.bodies())
+			assert.NotNil(t, s.Handle(someCtx, msgRec(someTime, "late")))
+		})
+	}
+}
+
+func TestHTTPSink_Latency(t *testing.T) {
+	srv := newHTTPRecorder(t)
+	s := yall.NewHTTPSink(srv.URL, &yall.HTTPSinkOptions{Format: yall.Message{}, MaxLatency: 10 * time.Millisecond})
+	defer s.Close(someCtx)
+
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "a")))
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "b")))
+	assert.Eventually(t, func() bool { return len(srv.bodies()) == 1 }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, []string{"a\nb\n"}, srv.bodies())
+}
+
+func TestHTTPSink_Request(t *testing.T) {
+	srv := newHTTPRecorder(t)
+	s := yall.NewHTTPSink(srv.URL, &yall.HTTPSinkOptions{Header: http.Header{"Authorization": {"Bearer x"}}})
+	assert.Nil(t, s.Handle(someCtx, rec("a", 1)))
+	assert.Nil(t, s.Flush(someCtx))
+
+	requests := srv.all()
+	require.Len(t, requests, 1)
+	r := requests[0]
+	assert.Equal(t, http.MethodPost, r.method)
+	assert.Equal(t, "gzip", r.header.Get("Content-Encoding"))
+	assert.Equal(t, "application/x-ndjson", r.header.Get("Content-Type"))
+	assert.Equal(t, "Bearer x", r.header.Get("Authorization"))
+	assert.Equal(t, `{"time":"2020-11-22T12:34:56.000000789Z","level":"INFO","msg":"msg","a":1}`+"\n", r.body)
+
+	s.Close(someCtx)
+}
+
+func TestHTTPSink_Uncompressed(t *testing.T) {
+	srv := newHTTPRecorder(t)
+	s := yall.NewHTTPSink(srv.URL, &yall.HTTPSinkOptions{Format: yall.Message{}, Uncompressed: true, ContentType: "text/plain"})
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "a")))
+	assert.Nil(t, s.Close(someCtx))
+
+	requests := srv.all()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "", requests[0].header.Get("Content-Encoding"))
+	assert.Equal(t, "text/plain", requests[0].header.Get("Content-Type"))
+	assert.Equal(t, "a\n", requests[0].body)
+}
+
+func TestHTTPSink_Retry(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     yall.HTTPSinkOptions
+		statuses []int
+		header   http.Header
+		requests int
+		errors   int
+	}{
+		{
+			name:     "ServerError",
+			statuses: []int{503, 500, 200},
+			requests: 3,
+		},
+		{
+			name:     "TooManyRequests",
+			statuses: []int{429, 200},
+			requests: 2,
+		},
+		{
+			name:     "RetryAfter",
+			opts:     yall.HTTPSinkOptions{MinBackoff: time.Hour},
+			statuses: []int{429, 200},
+			header:   http.Header{"Retry-After": {"0"}},
+			requests: 2,
+		},
+		{
+			name:     "RetryAfterDate",
+			opts:     yall.HTTPSinkOptions{MinBackoff: time.Hour},
+			statuses: []int{503, 200},
+			header:   http.Header{"Retry-After": {"Sun, 22 Nov 2020 12:34:56 GMT"}},
+			requests: 2,
+		},
+		{
+			name:     "ClientError",
+			statuses: []int{400, 200},
+			requests: 1,
+			errors:   1,
+		},
+		{
+			name:     "MaxRetries",
+			opts:     yall.HTTPSinkOptions{MaxRetries: 2},
+			statuses: []int{500, 500, 500, 200},
+			requests: 3,
+			errors:   1,
+		},
+		{
+			name:     "NoRetries",
+			opts:     yall.HTTPSinkOptions{MaxRetries: -1},
+			statuses: []int{500, 200},
+			requests: 1,
+			errors:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newHTTPRecorder(t)
+			srv.statuses = tt.statuses
+			srv.header = tt.header
+			var errs []error
+			opts := tt.opts
+			opts.Format = yall.Message{}
+			if opts.MinBackoff == 0 {
+				opts.MinBackoff = time.Millisecond
+			}
+			opts.OnError = func(err error) { errs = append(errs, err) }
+			s := yall.NewHTTPSink(srv.URL, &opts)
+			assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "a")))
+			assert.Nil(t, s.Close(someCtx))
+
+			requests := srv.all()
+			assert.Len(t, requests, tt.requests)
+			for _, r := range requests {
+				assert.Equal(t, "a\n", r.body)
+			}
+			assert.Len(t, errs, tt.errors)
+		})
+	}
+}
+
+func TestHTTPSink_RetryAfterDelay(t *testing.T) {
+	srv := newHTTPRecorder(t)
+	srv.statuses = []int{429, 200}
+	srv.header = http.Header{"Retry-After": {"1"}}
+	s := yall.NewHTTPSink(srv.URL, &yall.HTTPSinkOptions{
+		Format:     yall.Message{},
+		MinBackoff: time.Millisecond,
+		MaxBackoff: time.Millisecond,
+	})
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "a")))
+	assert.Nil(t, s.Close(someCtx))
+
+	requests := srv.all()
+	require.Len(t, requests, 2)
+	assert.GreaterOrEqual(t, requests[1].time.Sub(requests[0].time), time.Second)
+}
+
+func TestHTTPSink_Dropped(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+
+	s := yall.NewHTTPSink(srv.URL, &yall.HTTPSinkOptions{Format: yall.Message{}, MaxCount: 1, MaxPending: 1})
+	for _, m := range []string{"a", "b", "c", "d"} {
+		assert.Nil(t, s.Handle(someCtx, msgRec(someTime, m)))
+	}
+	assert.GreaterOrEqual(t, s.Dropped(), uint64(2))
+	close(release)
+	assert.Nil(t, s.Close(someCtx))
+}
+
+func TestHTTPSink_CloseTimeout(t *testing.T) {
+	srv := newHTTPRecorder(t)
+	srv.statuses = []int{503}
+	var errs atomic.Int32
+	s := yall.NewHTTPSink(srv.URL, &yall.HTTPSinkOptions{
+		Format:     yall.Message{},
+		MinBackoff: time.Hour,
+		OnError:    func(error) { errs.Add(1) },
+	})
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "a")))
+
+	ctx, cancel := context.WithTimeout(someCtx, 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Close(ctx), context.DeadlineExceeded)
+	assert.Eventually(t, func() bool { return s.Flush(someCtx) == nil && errs.Load() == 1 }, 5*time.Second, time.Millisecond)
+}
+
+func TestHTTPSink_Enabled(t *testing.T) {
+	s := yall.NewHTTPSink("http://localhost", &yall.HTTPSinkOptions{Level: slog.LevelWarn})
+	defer s.Close(someCtx)
+	assert.False(t, s.Enabled(someCtx, slog.LevelInfo))
+	assert.True(t, s.Enabled(someCtx, slog.LevelWarn))
+}
+
+type httpRequest struct {
+	method string
+	header http.Header
+	body   string
+	time   time.Time
+}
+
+// httpRecorder is a test server which records requests and responds with statuses in turn,
+// repeating the last one, along with header.
+type httpRecorder struct {
+	*httptest.Server
+	statuses []int
+	header   http.Header
+	lock     sync.Mutex
+	requests []httpRequest
+}
+
+func newHTTPRecorder(t *testing.T) *httpRecorder {
+	h := &httpRecorder{}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			assert.Nil(t, err)
+			body = zr
+		}
+		b, err := io.ReadAll(body)
+		assert.Nil(t, err)
+
+		h.lock.Lock()
+		h.requests = append(h.requests, httpRequest{method: r.Method, header: r.Header, body: string(b), time: time.Now()})
+		status := http.StatusOK
+		if len(h.statuses) != 0 {
+			status = h.statuses[min(len(h.requests), len(h.statuses))-1]
+		}
+		h.lock.Unlock()
+
+		for k, v := range h.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(h.Close)
+	return h
+}
+
+func (h *httpRecorder) all() []httpRequest {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return append([]httpRequest(nil), h.requests...)
+}
+
+func (h *httpRecorder) bodies() []string {
+	var bodies []string
+	for _, r := range h.all() {
+		bodies = append(bodies, r.body)
+	}
+	return bodies
+}
@@ -0,0 +1,211 @@
+package yall
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// JournaldSocket is the default address of the journal native protocol socket.
+const JournaldSocket = "/run/systemd/journal/socket"
+
+var errJournaldSinkClosed = errors.New("yall: journald sink is closed")
+
+var (
+	_ Sink   = (*JournaldSink)(nil)
+	_ Closer = (*JournaldSink)(nil)
+)
+
+// JournaldSink is a sink that sends records to systemd-journald using its native protocol.
+//
+// Each record becomes a journal entry with the fields MESSAGE, PRIORITY, SYSLOG_IDENTIFIER,
+// and CODE_FILE, CODE_LINE, CODE_FUNC if the record has a PC. Every attr is added
+// as a separate field. Keys of attrs in groups are joined with dots like in [TextAttrs].
+// Field names are converted to upper case, characters other than letters, digits and
+// underscores are replaced with underscores, and leading underscores and digits are removed,
+// so that "req.id" becomes REQ_ID. Attrs whose names end up empty are skipped.
+// Attrs whose names match the fields written by the sink, such as "message" or
+// "code_line", are prefixed with ATTR_, e.g. ATTR_MESSAGE.
+//
+// Entries too large for a datagram are written to an unlinked temporary file whose
+// descriptor is passed to journald instead. This is supported on unix systems only.
+//
+// The connection is established on the first Handle.
+type JournaldSink struct {
+	// Addr is the address of the journal socket. The default is [JournaldSocket].
+	Addr  string
+	Level slog.Leveler
+
+	// Format formats MESSAGE. The default is [Message].
+	Format Formatter
+
+	// Severities maps levels to PRIORITY, see [SyslogSink.Severities].
+	Severities map[slog.Level]SyslogSeverity
+
+	// Identifier is sent as SYSLOG_IDENTIFIER. The default is the executable name.
+	Identifier string
+
+	lock   sync.Mutex
+	conn   *net.UnixConn
+	buffer []byte
+	closed bool
+}
+
+func (s *JournaldSink) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= s.Level.Level()
+}
+
+func (s *JournaldSink) Handle(c context.Context, r slog.Record) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return errJournaldSinkClosed
+	}
+	addr := orDefault(s.Addr, JournaldSocket)
+	if s.conn == nil {
+		conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	s.buffer = s.appendEntry(s.buffer[:0], c, r)
+	_, err := s.conn.Write(s.buffer)
+	if isMessageTooLong(err) {
+		err = sendJournalFile(addr, s.buffer)
+	} else if err != nil {
+		// The journal may have been restarted, so connect again with the next record.
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// Close closes the connection. Handle fails after Close.
+func (s *JournaldSink) Close(context.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *JournaldSink) appendEntry(b []byte, c context.Context, r slog.Record) []byte {
+	var msg []byte
+	if s.Format == nil {
+		msg = append(msg, r.Message...)
+	} else {
+		msg = s.Format.Append(msg, c, r)
+	}
+	b = appendJournalField(b, "MESSAGE", msg)
+
+	severity := syslogSeverity(s.Severities, r.Level)
+	b = appendJournalField(b, "PRIORITY", strconv.AppendInt(nil, int64(severity), 10))
+
+	id := s.Identifier
+	if id == "" {
+		id = filepath.Base(os.Args[0])
+	}
+	b = appendJournalField(b, "SYSLOG_IDENTIFIER", []byte(id))
+
+	if r.PC != 0 {
+		src := recordSource(r.PC)
+		b = appendJournalField(b, "CODE_FILE", []byte(src.File))
+		b = appendJournalField(b, "CODE_LINE", strconv.AppendInt(nil, int64(src.Line), 10))
+		b = appendJournalField(b, "CODE_FUNC", []byte(src.Function))
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		b = appendJournalAttr(b, "", a)
+		return true
+	})
+	return b
+}
+
+func appendJournalAttr(b []byte, pfx string, a slog.Attr) []byte {
+	a.Value = a.Value.Resolve()
+	if isEmptyAttr(a) {
+		return b
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			pfx += a.Key + "."
+		}
+		for _, aa := range a.Value.Group() {
+			b = appendJournalAttr(b, pfx, aa)
+		}
+		return b
+	}
+	name := journalFieldName(pfx + a.Key)
+	if name == "" {
+		return b
+	}
+	if journalSinkFields[name] {
+		name = "ATTR_" + name
+	}
+	return appendJournalField(b, name, []byte(a.Value.String()))
+}
+
+// journalSinkFields are the fields written by JournaldSink itself.
+var journalSinkFields = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+// journalFieldName converts key to a valid journal field name, or returns an empty string
+// if there is nothing left of it.
+func journalFieldName(key string) string {
+	var sb strings.Builder
+	for _, c := range strings.ToUpper(key) {
+		switch {
+		case c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' || c == '_':
+			if sb.Len() == 0 {
+				continue
+			}
+		default:
+			if sb.Len() == 0 {
+				continue
+			}
+			c = '_'
+		}
+		sb.WriteRune(c)
+		if sb.Len() == 64 {
+			break
+		}
+	}
+	return sb.String()
+}
+
+// appendJournalField appends a field in the journal native format. Values without new lines
+// are written as NAME=value, other values as the name and a little-endian 64-bit length
+// followed by the value.
+func appendJournalField(b []byte, name string, value []byte) []byte {
+	b = append(b, name...)
+	if bytes.IndexByte(value, '\n') < 0 {
+		b = append(b, '=')
+	} else {
+		b = append(b, '\n')
+		b = binary.LittleEndian.AppendUint64(b, uint64(len(value)))
+	}
+	b = append(b, value...)
+	return append(b, '\n')
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package yall
+
+import "errors"
+
+func isMessageTooLong(error) bool {
+	return false
+}
+
+func sendJournalFile(string, []byte) error {
+	return errors.New("yall: passing journal entries as files is not supported on this system")
+}
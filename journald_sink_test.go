@@ -0,0 +1,225 @@
+//go:build unix
+
+package yall_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"github.com/snake-scaly/yall"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestJournaldSink_Handle(t *testing.T) {
+	tests := []struct {
+		name string
+		sink *yall.JournaldSink
+		rec  slog.Record
+		want map[string]string
+	}{
+		{
+			name: "Message",
+			sink: &yall.JournaldSink{},
+			rec:  msgRec(someTime, "hello"),
+			want: map[string]string{"MESSAGE": "hello", "PRIORITY": "6", "SYSLOG_IDENTIFIER": "app"},
+		},
+		{
+			name: "MultiLine",
+			sink: &yall.JournaldSink{},
+			rec:  slog.NewRecord(someTime, slog.LevelError, "one\ntwo", 0),
+			want: map[string]string{"MESSAGE": "one\ntwo", "PRIORITY": "3", "SYSLOG_IDENTIFIER": "app"},
+		},
+		{
+			name: "Format",
+			sink: &yall.JournaldSink{Format: yall.Layout{Format: "%s%s", Args: []yall.Formatter{yall.Message{}, yall.TextAttrs{}}}},
+			rec:  msgRec(someTime, "hello"),
+			want: map[string]string{"MESSAGE": "hello", "PRIORITY": "6", "SYSLOG_IDENTIFIER": "app"},
+		},
+		{
+			name: "Severities",
+			sink: &yall.JournaldSink{Severities: map[slog.Level]yall.SyslogSeverity{slog.LevelDebug: yall.SeverityAlert}},
+			rec:  msgRec(someTime, "hello"),
+			want: map[string]string{"MESSAGE": "hello", "PRIORITY": "1", "SYSLOG_IDENTIFIER": "app"},
+		},
+		{
+			name: "Attrs",
+			sink: &yall.JournaldSink{},
+			rec: func() slog.Record {
+				r := msgRec(someTime, "hello")
+				r.AddAttrs(
+					slog.Int("count", 3),
+					slog.Group("req", "id", "x-1", slog.Group("", "inline", true)),
+					slog.String("text", "a\nb"),
+					slog.String("_trusted", "t"),
+					slog.String("9lives", "cat"),
+					slog.String("ünï-côde", "u"),
+					slog.String("---", "skipped"),
+					slog.Group("empty"),
+				)
+				return r
+			}(),
+			want: map[string]string{
+				"MESSAGE":           "hello",
+				"PRIORITY":          "6",
+				"SYSLOG_IDENTIFIER": "app",
+				"COUNT":             "3",
+				"REQ_ID":            "x-1",
+				"REQ_INLINE":        "true",
+				"TEXT":              "a\nb",
+				"TRUSTED":           "t",
+				"LIVES":             "cat",
+				"N__C_DE":           "u",
+			},
+		},
+		{
+			name: "SinkFields",
+			sink: &yall.JournaldSink{},
+			rec: func() slog.Record {
+				r := msgRec(someTime, "hello")
+				r.AddAttrs(
+					slog.String("message", "fake"),
+					slog.Int("priority", 7),
+					slog.Group("syslog", "identifier", "other"),
+					slog.String("code_line", "1"),
+				)
+				return r
+			}(),
+			want: map[string]string{
+				"MESSAGE":                "hello",
+				"PRIORITY":               "6",
+				"SYSLOG_IDENTIFIER":      "app",
+				"ATTR_MESSAGE":           "fake",
+				"ATTR_PRIORITY":          "7",
+				"ATTR_CODE_LINE":         "1",
+				"ATTR_SYSLOG_IDENTIFIER": "other",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := listenJournal(t)
+			s := tt.sink
+			s.Addr = conn.LocalAddr().String()
+			s.Identifier = "app"
+			assert.Nil(t, s.Handle(someCtx, tt.rec))
+			assert.Nil(t, s.Close(someCtx))
+			assert.Equal(t, tt.want, readJournalEntry(t, conn))
+		})
+	}
+}
+
+func TestJournaldSink_Source(t *testing.T) {
+	conn := listenJournal(t)
+	s := &yall.JournaldSink{Addr: conn.LocalAddr().String()}
+	assert.Nil(t, s.Handle(someCtx, rec()))
+	assert.Nil(t, s.Close(someCtx))
+
+	fields := readJournalEntry(t, conn)
+	assert.True(t, strings.HasSuffix(fields["CODE_FILE"], "util_test.go"))
+	assert.Equal(t, "14", fields["CODE_LINE"])
+	assert.Equal(t, "github.com/snake-scaly/yall_test.rec", fields["CODE_FUNC"])
+	assert.Equal(t, filepath.Base(os.Args[0]), fields["SYSLOG_IDENTIFIER"])
+}
+
+func TestJournaldSink_Large(t *testing.T) {
+	conn := listenJournal(t)
+	s := &yall.JournaldSink{Addr: conn.LocalAddr().String(), Identifier: "app"}
+	msg := strings.Repeat("x", 4<<20)
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, msg)))
+	assert.Nil(t, s.Close(someCtx))
+
+	assert.Equal(t, map[string]string{"MESSAGE": msg, "PRIORITY": "6", "SYSLOG_IDENTIFIER": "app"}, readJournalEntry(t, conn))
+}
+
+func TestJournaldSink_Reconnect(t *testing.T) {
+	conn := listenJournal(t)
+	addr := conn.LocalAddr().String()
+	s := &yall.JournaldSink{Addr: addr, Identifier: "app"}
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "one")))
+	assert.Equal(t, "one", readJournalEntry(t, conn)["MESSAGE"])
+
+	require.Nil(t, conn.Close())
+	require.Nil(t, os.Remove(addr))
+	assert.NotNil(t, s.Handle(someCtx, msgRec(someTime, "lost")))
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	require.Nil(t, err)
+	defer conn.Close()
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "two")))
+	assert.Nil(t, s.Close(someCtx))
+	assert.Equal(t, "two", readJournalEntry(t, conn)["MESSAGE"])
+}
+
+func TestJournaldSink_Closed(t *testing.T) {
+	conn := listenJournal(t)
+	s := &yall.JournaldSink{Addr: conn.LocalAddr().String()}
+	assert.Nil(t, s.Close(someCtx))
+	assert.NotNil(t, s.Handle(someCtx, msgRec(someTime, "hello")))
+}
+
+func TestJournaldSink_Enabled(t *testing.T) {
+	s := &yall.JournaldSink{Level: slog.LevelWarn}
+	assert.False(t, s.Enabled(someCtx, slog.LevelInfo))
+	assert.True(t, s.Enabled(someCtx, slog.LevelWarn))
+}
+
+func listenJournal(t *testing.T) *net.UnixConn {
+	addr := &net.UnixAddr{Name: filepath.Join(t.TempDir(), "socket"), Net: "unixgram"}
+	conn, err := net.ListenUnixgram("unixgram", addr)
+	require.Nil(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// readJournalEntry receives an entry sent either as a datagram or as a file descriptor.
+func readJournalEntry(t *testing.T, conn *net.UnixConn) map[string]string {
+	require.Nil(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 1<<16)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	require.Nil(t, err)
+	data := buf[:n]
+
+	if oobn != 0 {
+		msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		require.Nil(t, err)
+		require.Len(t, msgs, 1)
+		fds, err := syscall.ParseUnixRights(&msgs[0])
+		require.Nil(t, err)
+		require.Len(t, fds, 1)
+		f := os.NewFile(uintptr(fds[0]), "entry")
+		defer f.Close()
+		_, err = f.Seek(0, io.SeekStart)
+		require.Nil(t, err)
+		data, err = io.ReadAll(f)
+		require.Nil(t, err)
+	}
+
+	fields := map[string]string{}
+	for len(data) != 0 {
+		i := bytes.IndexByte(data, '\n')
+		require.True(t, i > 0)
+		line := string(data[:i])
+		data = data[i+1:]
+		if name, value, ok := strings.Cut(line, "="); ok {
+			fields[name] = value
+			continue
+		}
+		require.True(t, len(data) >= 8)
+		size := binary.LittleEndian.Uint64(data)
+		data = data[8:]
+		fields[line] = string(data[:size])
+		require.Equal(t, byte('\n'), data[size])
+		data = data[size+1:]
+	}
+	return fields
+}
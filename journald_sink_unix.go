@@ -0,0 +1,44 @@
+//go:build unix
+
+package yall
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func isMessageTooLong(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+// sendJournalFile writes entry to an unlinked temporary file and passes its descriptor
+// to the journal socket at addr, which is what sd_journal_send does when memfd is unavailable.
+func sendJournalFile(addr string, entry []byte) error {
+	// Journald only accepts unsealed files from /dev/shm, /tmp, and /var/tmp.
+	dir := "/dev/shm"
+	if _, err := os.Stat(dir); err != nil {
+		dir = os.TempDir()
+	}
+	f, err := os.CreateTemp(dir, "journal-")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return err
+	}
+	if _, err := f.Write(entry); err != nil {
+		return err
+	}
+
+	// The net package refuses to send control messages over connected datagram sockets.
+	sock, err := syscall.Socket(syscall.AF_UNIX, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return os.NewSyscallError("socket", err)
+	}
+	defer syscall.Close(sock)
+	rights := syscall.UnixRights(int(f.Fd()))
+	err = syscall.Sendmsg(sock, nil, rights, &syscall.SockaddrUnix{Name: addr}, 0)
+	return os.NewSyscallError("sendmsg", err)
+}
@@ -0,0 +1,346 @@
+package yall
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// JSON is a [Formatter] that formats the whole [slog.Record] as a single JSON object.
+// The output is byte-compatible with [slog.JSONHandler]: the keys are "time", "level",
+// "source" and "msg", followed by [slog.Record.Attrs]. Groups become nested objects,
+// empty groups are omitted, and groups with empty keys are inlined.
+// Zero [slog.Record.Time] is omitted. A stack trace captured by the handler,
+// see [HandlerOptions.StackLevel], is added last with the key [StackKey].
+// The keys of the built-in attributes can be renamed, which combined with
+// [HandlerOptions.ReplaceAttr] covers what a ReplaceAttr function
+// of slog.JSONHandler can do.
+//
+// Values are resolved with [slog.Value.Resolve]. Errors are formatted using their
+// Error method unless they implement [json.Marshaler], []byte is formatted as a base64
+// string, and [time.Duration] as an integer number of nanoseconds. Other values are
+// encoded with [json.Encoder] without HTML escaping. Encoding failures are formatted
+// as a string value starting with "!ERROR:".
+type JSON struct {
+	// AddSource enables the "source" object with the function, file and line
+	// of [slog.Record.PC].
+	AddSource bool
+
+	// TimeKey, LevelKey, SourceKey and MessageKey replace the keys of the built-in
+	// attributes. Empty values select the keys of [slog.JSONHandler].
+	TimeKey    string
+	LevelKey   string
+	SourceKey  string
+	MessageKey string
+
+	// SourceOptions configures the function and file in the "source" object,
+	// see [Source]. Short file names, Function, TrimPrefix, and Relative apply.
+	// The zero value matches slog.JSONHandler.
+	SourceOptions Source
+
+	// Levels names the levels. If Levels is nil, levels are named like [slog.JSONHandler].
+	Levels *LevelNames
+}
+
+func (j JSON) Append(b []byte, c context.Context, r slog.Record) []byte {
+	b = append(b, '{')
+	if !r.Time.IsZero() {
+		b = appendJSONKey(b, orDefault(j.TimeKey, slog.TimeKey))
+		b = appendJSONValue(b, slog.TimeValue(r.Time.Round(0)))
+		b = append(b, ',')
+	}
+	b = appendJSONKey(b, orDefault(j.LevelKey, slog.LevelKey))
+	b = appendJSONString(b, levelName(j.Levels, r.Level))
+	if j.AddSource && r.PC != 0 {
+		b, _ = appendJSONAttr(b, slog.Attr{Key: orDefault(j.SourceKey, slog.SourceKey), Value: j.SourceOptions.group(r.PC)}, true)
+	}
+	b = append(b, ',')
+	b = appendJSONKey(b, orDefault(j.MessageKey, slog.MessageKey))
+	b = appendJSONString(b, r.Message)
+	r.Attrs(func(a slog.Attr) bool {
+		b, _ = appendJSONAttr(b, a, true)
+		return true
+	})
+	if s := ContextStack(c); len(s) != 0 {
+		b, _ = appendJSONAttr(b, slog.Any(StackKey, s), true)
+	}
+	return append(b, '}')
+}
+
+// JSONAttrs is a [Formatter] that formats [slog.Record.Attrs] as a JSON object.
+// Attributes are formatted the same way as by [JSON].
+//
+// When Members is true, the braces are omitted and each attribute is preceded
+// by a comma instead. The result is empty if there are no attributes. This allows
+// extending an object in a [Layout], for example:
+//
+//	yall.Layout{
+//		Format: `{"msg":%s%s}`,
+//		Args:   []yall.Formatter{yall.Message{Quote: yall.QuoteJSON}, yall.JSONAttrs{Members: true}},
+//	}
+type JSONAttrs struct {
+	Members bool
+}
+
+func (j JSONAttrs) Append(b []byte, _ context.Context, r slog.Record) []byte {
+	if !j.Members {
+		b = append(b, '{')
+	}
+	sep := j.Members
+	r.Attrs(func(a slog.Attr) bool {
+		var ok bool
+		if b, ok = appendJSONAttr(b, a, sep); ok {
+			sep = true
+		}
+		return true
+	})
+	if !j.Members {
+		b = append(b, '}')
+	}
+	return b
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// appendJSONAttr appends a as a JSON object member, preceded by a comma if sep is true.
+// Empty attrs and empty groups are skipped. Groups with empty keys are inlined.
+// Reports whether anything was appended.
+func appendJSONAttr(b []byte, a slog.Attr, sep bool) ([]byte, bool) {
+	a.Value = a.Value.Resolve()
+	if isEmptyAttr(a) {
+		return b, false
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		if sep {
+			b = append(b, ',')
+		}
+		b = appendJSONKey(b, a.Key)
+		return appendJSONValue(b, a.Value), true
+	}
+
+	pos := len(b)
+	inner := sep
+	if a.Key != "" {
+		if sep {
+			b = append(b, ',')
+		}
+		b = appendJSONKey(b, a.Key)
+		b = append(b, '{')
+		inner = false
+	}
+	empty := true
+	for _, aa := range a.Value.Group() {
+		var ok bool
+		if b, ok = appendJSONAttr(b, aa, inner); ok {
+			inner = true
+			empty = false
+		}
+	}
+	if empty {
+		return b[:pos], false
+	}
+	if a.Key != "" {
+		b = append(b, '}')
+	}
+	return b, true
+}
+
+// isEmptyAttr reports whether a is the zero Attr which slog handlers ignore.
+func isEmptyAttr(a slog.Attr) bool {
+	return a.Key == "" && a.Value.Kind() == slog.KindAny && a.Value.Any() == nil
+}
+
+func appendJSONKey(b []byte, k string) []byte {
+	b = appendJSONString(b, k)
+	return append(b, ':')
+}
+
+// appendJSONValue appends a resolved non-group value. Encoding errors are appended
+// as strings in the manner of [slog.JSONHandler].
+func appendJSONValue(b []byte, v slog.Value) []byte {
+	pos := len(b)
+	b, err := appendJSONValueErr(b, v)
+	if err != nil {
+		b = appendJSONString(b[:pos], fmt.Sprintf("!ERROR:%v", err))
+	}
+	return b
+}
+
+func appendJSONValueErr(b []byte, v slog.Value) ([]byte, error) {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendJSONString(b, v.String()), nil
+	case slog.KindInt64:
+		return strconv.AppendInt(b, v.Int64(), 10), nil
+	case slog.KindUint64:
+		return strconv.AppendUint(b, v.Uint64(), 10), nil
+	case slog.KindFloat64:
+		return appendJSONFloat(b, v.Float64())
+	case slog.KindBool:
+		return strconv.AppendBool(b, v.Bool()), nil
+	case slog.KindDuration:
+		return strconv.AppendInt(b, int64(v.Duration()), 10), nil
+	case slog.KindTime:
+		return appendJSONTime(b, v.Time())
+	}
+
+	return appendJSONAny(b, v.Any())
+}
+
+func appendJSONAny(b []byte, a any) (res []byte, err error) {
+	pos := len(b)
+	defer func() {
+		if r := recover(); r != nil {
+			if rv := reflect.ValueOf(a); rv.Kind() == reflect.Pointer && rv.IsNil() {
+				res, err = appendJSONString(b[:pos], "<nil>"), nil
+			} else {
+				res, err = appendJSONString(b[:pos], fmt.Sprintf("!PANIC: %v", r)), nil
+			}
+		}
+	}()
+
+	switch a := a.(type) {
+	case json.Marshaler:
+		return appendJSONMarshal(b, a)
+	case error:
+		return appendJSONString(b, a.Error()), nil
+	case []byte:
+		b = append(b, '"')
+		b = base64.StdEncoding.AppendEncode(b, a)
+		return append(b, '"'), nil
+	default:
+		return appendJSONMarshal(b, a)
+	}
+}
+
+// appendJSONString appends s as a quoted JSON string. The escaping is identical
+// to [json.Encoder] with HTML escaping disabled.
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= ' ' && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, jsonInvalidUTF8...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// jsonInvalidUTF8 is how encoding/json replaces invalid UTF-8. Depending on the Go
+// version it is either an escape sequence or a literal U+FFFD.
+var jsonInvalidUTF8 = func() string {
+	b, _ := json.Marshal("\xff")
+	return string(b[1 : len(b)-1])
+}()
+
+// appendJSONFloat appends f the same way [json.Marshal] does.
+func appendJSONFloat(b []byte, f float64) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return b, errors.New("json: unsupported value: " + strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	b = strconv.AppendFloat(b, f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b, nil
+}
+
+// appendJSONTime appends t the same way [time.Time.MarshalJSON] does.
+func appendJSONTime(b []byte, t time.Time) ([]byte, error) {
+	if y := t.Year(); y < 0 || y >= 10000 {
+		return b, errors.New("time.Time year outside of range [0,9999]")
+	}
+	b = append(b, '"')
+	b = t.AppendFormat(b, time.RFC3339Nano)
+	return append(b, '"'), nil
+}
+
+type jsonEncoder struct {
+	buf *bytes.Buffer
+	enc *json.Encoder
+}
+
+var jsonEncoderPool = sync.Pool{
+	New: func() any {
+		e := &jsonEncoder{buf: new(bytes.Buffer)}
+		e.enc = json.NewEncoder(e.buf)
+		e.enc.SetEscapeHTML(false)
+		return e
+	},
+}
+
+func appendJSONMarshal(b []byte, v any) ([]byte, error) {
+	e := jsonEncoderPool.Get().(*jsonEncoder)
+	defer func() {
+		e.buf.Reset()
+		jsonEncoderPool.Put(e)
+	}()
+
+	if err := e.enc.Encode(v); err != nil {
+		return b, err
+	}
+	bs := e.buf.Bytes()
+	return append(b, bs[:len(bs)-1]...), nil
+}
@@ -0,0 +1,185 @@
+package yall_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"math"
+	"github.com/snake-scaly/yall"
+	"testing"
+	"time"
+)
+
+func TestJSON_Append(t *testing.T) {
+	tests := []struct {
+		name string
+		rec  slog.Record
+	}{
+		{
+			name: "Empty",
+			rec:  rec(),
+		},
+		{
+			name: "ZeroTime",
+			rec:  slog.NewRecord(time.Time{}, slog.LevelWarn, "msg", 0),
+		},
+		{
+			name: "Scalars",
+			rec: rec(
+				"s", "str",
+				"i", -42,
+				"u", uint64(42),
+				"f", 1.5,
+				"e", 1e-7,
+				"big", 1e21,
+				"b", true,
+				"d", 3*time.Second,
+				"t", someTime,
+			),
+		},
+		{
+			name: "Escaping",
+			rec:  rec("k\"ey", "a\"b\\c\nd\re\tf\x01<>&  \xffé"),
+		},
+		{
+			name: "Any",
+			rec: rec(
+				"err", errors.New("boom"),
+				"bytes", []byte("hello"),
+				"map", map[string]int{"a": 1},
+				"slice", []string{"x", "y"},
+				"nil", nil,
+				"marshaler", jsonMarshaler{},
+				"nan", math.NaN(),
+			),
+		},
+		{
+			name: "Groups",
+			rec: rec(
+				"a", "b",
+				slog.Group("g", "x", "y", slog.Group("h", "v", "w")),
+				slog.Group("empty"),
+				slog.Group("", "inline", 1),
+				slog.Group("nested", slog.Group("empty")),
+			),
+		},
+		{
+			name: "LogValuer",
+			rec:  rec("lv", testLogValuer{slog.GroupValue(slog.String("k", "v"))}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, source := range []bool{false, true} {
+				want := bytes.Buffer{}
+				h := slog.NewJSONHandler(&want, &slog.HandlerOptions{AddSource: source})
+				assert.Nil(t, h.Handle(someCtx, tt.rec))
+
+				f := yall.JSON{AddSource: source}
+				s := formatToString(f, someCtx, tt.rec)
+				assert.Equal(t, want.String(), s+"\n")
+			}
+		})
+	}
+}
+
+func TestJSON_Keys(t *testing.T) {
+	want := bytes.Buffer{}
+	h := slog.NewJSONHandler(&want, &slog.HandlerOptions{
+		AddSource: true,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if k, ok := map[string]string{"time": "ts", "level": "lvl", "source": "src", "msg": "message"}[a.Key]; ok && len(groups) == 0 {
+				a.Key = k
+			}
+			return a
+		},
+	})
+	r := rec()
+	assert.Nil(t, h.Handle(someCtx, r))
+
+	f := yall.JSON{AddSource: true, TimeKey: "ts", LevelKey: "lvl", SourceKey: "src", MessageKey: "message"}
+	s := formatToString(f, someCtx, r)
+	assert.Equal(t, want.String(), s+"\n")
+}
+
+func TestJSON_Levels(t *testing.T) {
+	f := yall.JSON{Levels: &yall.LevelNames{Case: yall.CaseLower}}
+	r := slog.NewRecord(time.Time{}, slog.LevelWarn, "msg", 0)
+	assert.Equal(t, `{"level":"warn","msg":"msg"}`, formatToString(f, nil, r))
+}
+
+func TestJSONAttrs_Append(t *testing.T) {
+	tests := []struct {
+		name    string
+		rec     slog.Record
+		members bool
+		want    string
+	}{
+		{
+			name: "Empty",
+			rec:  rec(),
+			want: "{}",
+		},
+		{
+			name:    "EmptyMembers",
+			rec:     rec(),
+			members: true,
+			want:    "",
+		},
+		{
+			name: "Object",
+			rec:  rec("a", "b", slog.Group("g", "x", 1), slog.Group("e"), "c", "d"),
+			want: `{"a":"b","g":{"x":1},"c":"d"}`,
+		},
+		{
+			name:    "Members",
+			rec:     rec("a", "b", slog.Group("g", "x", 1), slog.Group("e"), "c", "d"),
+			members: true,
+			want:    `,"a":"b","g":{"x":1},"c":"d"`,
+		},
+		{
+			name: "LeadingEmptyGroup",
+			rec:  rec(slog.Group("e"), "a", "b"),
+			want: `{"a":"b"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := yall.JSONAttrs{Members: tt.members}
+			s := formatToString(f, nil, tt.rec)
+			assert.Equal(t, tt.want, s)
+		})
+	}
+}
+
+func TestJSONAttrs_Layout(t *testing.T) {
+	f := yall.Layout{
+		Format: `{"msg":%s,"ctx":%s}`,
+		Args:   []yall.Formatter{yall.Message{Quote: yall.QuoteJSON}, yall.JSONAttrs{}},
+	}
+	r := slog.NewRecord(someTime, slog.LevelInfo, "say \"hi\"", 0)
+	r.Add("a", 1)
+	s := formatToString(f, nil, r)
+	assert.Equal(t, `{"msg":"say \"hi\"","ctx":{"a":1}}`, s)
+}
+
+type jsonMarshaler struct{}
+
+func (jsonMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`{ "custom" : [1, 2] }`), nil
+}
+
+func (jsonMarshaler) Error() string {
+	return "not used"
+}
+
+type testLogValuer struct {
+	v slog.Value
+}
+
+func (l testLogValuer) LogValue() slog.Value {
+	return l.v
+}
@@ -0,0 +1,166 @@
+package yall
+
+import (
+	"log/slog"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Levels commonly used in addition to the ones defined by slog.
+// See [ExtendedLevelNames] for their names.
+const (
+	LevelTrace  = slog.Level(-8)
+	LevelNotice = slog.Level(2)
+	LevelFatal  = slog.Level(12)
+)
+
+// LetterCase defines the case of a name.
+type LetterCase int
+
+const (
+	CaseAsIs  = LetterCase(iota) // Keep the case of the name.
+	CaseUpper                    // Convert the name to upper case, e.g. "INFO".
+	CaseLower                    // Convert the name to lower case, e.g. "info".
+	CaseTitle                    // Convert the first letter to upper case and the rest to lower case, e.g. "Info".
+)
+
+// LevelAbbrev defines how level names are abbreviated.
+type LevelAbbrev int
+
+const (
+	AbbrevNone  = LevelAbbrev(iota) // Do not abbreviate.
+	AbbrevChar                      // Use the first letter of the name, e.g. "I".
+	AbbrevThree                     // Use three letters in the case of the name, e.g. "DBG" or "inf".
+)
+
+// LevelNames is a table of level names shared by formatters which output levels,
+// such as [Level] and [JSON].
+//
+// A level is named by its entry in Exact if there is one. Otherwise the entry in Ranged
+// with the greatest level not exceeding the level is used. Otherwise the name
+// is [slog.Level.String], e.g. "INFO+2". The name is then abbreviated according to Abbrev,
+// which drops any offset such as "+2", and converted to Case.
+//
+// The zero value names levels with [slog.Level.String]. Since the tables are maps,
+// LevelNames values are not comparable, so formatters refer to them by pointer.
+type LevelNames struct {
+	Exact  map[slog.Level]string
+	Ranged map[slog.Level]string
+	Case   LetterCase
+	Abbrev LevelAbbrev
+}
+
+// ExtendedLevelNames returns a table which adds exact names for [LevelTrace],
+// [LevelNotice], and [LevelFatal] to the standard slog names.
+func ExtendedLevelNames() LevelNames {
+	return LevelNames{
+		Exact: map[slog.Level]string{
+			LevelTrace:      "TRACE",
+			slog.LevelDebug: "DEBUG",
+			slog.LevelInfo:  "INFO",
+			LevelNotice:     "NOTICE",
+			slog.LevelWarn:  "WARN",
+			slog.LevelError: "ERROR",
+			LevelFatal:      "FATAL",
+		},
+	}
+}
+
+// Name returns the name of l.
+func (n LevelNames) Name(l slog.Level) string {
+	name, ok := n.Exact[l]
+	if !ok {
+		name, ok = nearestLevel(n.Ranged, l)
+	}
+	if !ok {
+		name = l.String()
+	}
+	return setCase(abbreviate(name, n.Abbrev), n.Case)
+}
+
+// levelName returns the name of l in n, or [slog.Level.String] if n is nil.
+func levelName(n *LevelNames, l slog.Level) string {
+	if n == nil {
+		return l.String()
+	}
+	return n.Name(l)
+}
+
+// nearestLevel returns the entry of m with the greatest level not exceeding l.
+func nearestLevel[T any](m map[slog.Level]T, l slog.Level) (v T, found bool) {
+	var best slog.Level
+	for level, x := range m {
+		if level <= l && (!found || level > best) {
+			v, best, found = x, level, true
+		}
+	}
+	return v, found
+}
+
+// threeLetterNames are the abbreviations of well known level names for [AbbrevThree].
+var threeLetterNames = map[string]string{
+	"TRACE":    "TRC",
+	"DEBUG":    "DBG",
+	"INFO":     "INF",
+	"NOTICE":   "NTC",
+	"WARN":     "WRN",
+	"WARNING":  "WRN",
+	"ERROR":    "ERR",
+	"CRITICAL": "CRT",
+	"FATAL":    "FTL",
+	"PANIC":    "PNC",
+}
+
+func abbreviate(name string, a LevelAbbrev) string {
+	if a == AbbrevNone || name == "" {
+		return name
+	}
+	if i := strings.IndexAny(name, "+-"); i > 0 {
+		name = name[:i]
+	}
+	switch a {
+	case AbbrevChar:
+		_, n := utf8.DecodeRuneInString(name)
+		return name[:n]
+	case AbbrevThree:
+		if s, ok := threeLetterNames[strings.ToUpper(name)]; ok {
+			return setCase(s, caseOf(name))
+		}
+		for i := range name {
+			if utf8.RuneCountInString(name[:i]) == 3 {
+				return name[:i]
+			}
+		}
+	}
+	return name
+}
+
+// caseOf returns the case of s, or CaseUpper if s has mixed case other than title case.
+func caseOf(s string) LetterCase {
+	switch s {
+	case strings.ToUpper(s):
+		return CaseUpper
+	case strings.ToLower(s):
+		return CaseLower
+	case setCase(s, CaseTitle):
+		return CaseTitle
+	}
+	return CaseUpper
+}
+
+func setCase(s string, c LetterCase) string {
+	switch c {
+	case CaseUpper:
+		return strings.ToUpper(s)
+	case CaseLower:
+		return strings.ToLower(s)
+	case CaseTitle:
+		if s == "" {
+			return s
+		}
+		r, n := utf8.DecodeRuneInString(s)
+		return string(unicode.ToUpper(r)) + strings.ToLower(s[n:])
+	}
+	return s
+}
@@ -0,0 +1,122 @@
+package yall_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"github.com/snake-scaly/yall"
+	"testing"
+)
+
+func TestLevelNames_Name(t *testing.T) {
+	tests := []struct {
+		name  string
+		names yall.LevelNames
+		level slog.Level
+		want  string
+	}{
+		{
+			name:  "Default",
+			level: slog.LevelInfo + 2,
+			want:  "INFO+2",
+		},
+		{
+			name:  "Extended",
+			names: yall.ExtendedLevelNames(),
+			level: yall.LevelNotice,
+			want:  "NOTICE",
+		},
+		{
+			name:  "ExtendedFallback",
+			names: yall.ExtendedLevelNames(),
+			level: slog.LevelInfo + 1,
+			want:  "INFO+1",
+		},
+		{
+			name:  "Ranged",
+			names: yall.LevelNames{Ranged: map[slog.Level]string{slog.LevelInfo: "info", slog.LevelError: "error"}},
+			level: slog.LevelWarn,
+			want:  "info",
+		},
+		{
+			name: "ExactOverRanged",
+			names: yall.LevelNames{
+				Exact:  map[slog.Level]string{slog.LevelWarn: "warning"},
+				Ranged: map[slog.Level]string{slog.LevelInfo: "info"},
+			},
+			level: slog.LevelWarn,
+			want:  "warning",
+		},
+		{
+			name:  "BelowRanged",
+			names: yall.LevelNames{Ranged: map[slog.Level]string{slog.LevelInfo: "info"}},
+			level: slog.LevelDebug,
+			want:  "DEBUG",
+		},
+		{
+			name:  "Lower",
+			names: yall.LevelNames{Case: yall.CaseLower},
+			level: slog.LevelWarn,
+			want:  "warn",
+		},
+		{
+			name:  "Title",
+			names: yall.LevelNames{Case: yall.CaseTitle},
+			level: slog.LevelError,
+			want:  "Error",
+		},
+		{
+			name:  "Char",
+			names: yall.LevelNames{Abbrev: yall.AbbrevChar},
+			level: slog.LevelWarn + 1,
+			want:  "W",
+		},
+		{
+			name:  "Three",
+			names: yall.LevelNames{Abbrev: yall.AbbrevThree, Case: yall.CaseLower},
+			level: slog.LevelDebug,
+			want:  "dbg",
+		},
+		{
+			name:  "ThreeExtended",
+			names: func() yall.LevelNames { n := yall.ExtendedLevelNames(); n.Abbrev = yall.AbbrevThree; return n }(),
+			level: yall.LevelFatal,
+			want:  "FTL",
+		},
+		{
+			name:  "ThreeKeepsLower",
+			names: yall.LevelNames{Exact: map[slog.Level]string{slog.LevelInfo: "info"}, Abbrev: yall.AbbrevThree},
+			level: slog.LevelInfo,
+			want:  "inf",
+		},
+		{
+			name:  "ThreeKeepsTitle",
+			names: yall.LevelNames{Exact: map[slog.Level]string{slog.LevelWarn: "Warning"}, Abbrev: yall.AbbrevThree},
+			level: slog.LevelWarn,
+			want:  "Wrn",
+		},
+		{
+			name:  "ThreeUnknown",
+			names: yall.LevelNames{Exact: map[slog.Level]string{1: "VERBOSE"}, Abbrev: yall.AbbrevThree},
+			level: 1,
+			want:  "VER",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.names.Name(tt.level))
+		})
+	}
+}
+
+func TestLevel_Append(t *testing.T) {
+	assert.Equal(t, "INFO", formatToString(yall.Level{}, nil, rec()))
+	l := yall.Level{Names: &yall.LevelNames{Abbrev: yall.AbbrevChar}}
+	assert.Equal(t, "I", formatToString(l, nil, rec()))
+}
+
+func TestLevel_Comparable(t *testing.T) {
+	n := yall.ExtendedLevelNames()
+	var a, b yall.Formatter = yall.Level{Names: &n}, yall.Level{Names: &n}
+	assert.True(t, a == b)
+}
@@ -0,0 +1,139 @@
+package yall
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Logfmt is a [Formatter] that formats [slog.Record.Attrs] as logfmt "key=value" pairs.
+// Unlike [TextAttrs], the output is always valid logfmt which standard parsers read back
+// exactly:
+//
+//   - Values are resolved with [slog.Value.Resolve] and converted to text the same way
+//     as by [slog.TextHandler].
+//   - Values are quoted if they are empty or contain spaces, control characters,
+//     '=', '"', or invalid UTF-8. Quoted values use JSON escape sequences.
+//   - Keys of nested groups are joined with dots. Empty groups are omitted,
+//     and groups with empty keys are inlined.
+//   - Logfmt has no way to quote a key, so spaces, control characters, '=', '"' and
+//     invalid UTF-8 in keys are replaced with '_'. An empty key is written as "_".
+//
+// When the result is non-empty, it includes a leading space.
+type Logfmt struct{}
+
+func (l Logfmt) Append(b []byte, _ context.Context, r slog.Record) []byte {
+	r.Attrs(func(a slog.Attr) bool {
+		b = appendLogfmtAttr(b, "", a)
+		return true
+	})
+	return b
+}
+
+func appendLogfmtAttr(b []byte, pfx string, a slog.Attr) []byte {
+	a.Value = a.Value.Resolve()
+	if isEmptyAttr(a) {
+		return b
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			pfx += a.Key + "."
+		}
+		for _, aa := range a.Value.Group() {
+			b = appendLogfmtAttr(b, pfx, aa)
+		}
+		return b
+	}
+	b = append(b, ' ')
+	b = appendLogfmtKey(b, pfx+a.Key)
+	b = append(b, '=')
+	return appendLogfmtValue(b, a.Value)
+}
+
+func appendLogfmtKey(b []byte, k string) []byte {
+	if k == "" {
+		return append(b, '_')
+	}
+	start := 0
+	for i := 0; i < len(k); {
+		r, size := utf8.DecodeRuneInString(k[i:])
+		if isLogfmtSpecial(r) {
+			b = append(b, k[start:i]...)
+			b = append(b, '_')
+			start = i + size
+		}
+		i += size
+	}
+	return append(b, k[start:]...)
+}
+
+func appendLogfmtValue(b []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendLogfmtString(b, v.String())
+	case slog.KindInt64:
+		return strconv.AppendInt(b, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(b, v.Uint64(), 10)
+	case slog.KindFloat64:
+		return strconv.AppendFloat(b, v.Float64(), 'g', -1, 64)
+	case slog.KindBool:
+		return strconv.AppendBool(b, v.Bool())
+	case slog.KindDuration:
+		return append(b, v.Duration().String()...)
+	case slog.KindTime:
+		return appendRFC3339Millis(b, v.Time())
+	default:
+		return appendLogfmtString(b, anyText(v.Any()))
+	}
+}
+
+// appendLogfmtString appends s, quoting it only if logfmt requires it.
+func appendLogfmtString(b []byte, s string) []byte {
+	if s == "" {
+		return append(b, '"', '"')
+	}
+	for _, r := range s {
+		if isLogfmtSpecial(r) {
+			return appendJSONString(b, s)
+		}
+	}
+	return append(b, s...)
+}
+
+func isLogfmtSpecial(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == 0x7f || r == utf8.RuneError
+}
+
+// anyText converts a to text the same way as [slog.TextHandler] does.
+func anyText(a any) string {
+	switch a := a.(type) {
+	case encoding.TextMarshaler:
+		t, err := a.MarshalText()
+		if err != nil {
+			return fmt.Sprintf("!ERROR:%v", err)
+		}
+		return string(t)
+	case []byte:
+		return string(a)
+	default:
+		return fmt.Sprintf("%+v", a)
+	}
+}
+
+// appendRFC3339Millis formats t the same way as [slog.TextHandler] does.
+func appendRFC3339Millis(b []byte, t time.Time) []byte {
+	// Format according to time.RFC3339Nano since it is highly optimized,
+	// but truncate it to use millisecond resolution.
+	// Unfortunately, that format trims trailing 0s, so add 1/10 millisecond
+	// to guarantee that there are exactly 4 digits after the period.
+	const prefixLen = len("2006-01-02T15:04:05.000")
+	n := len(b)
+	t = t.Truncate(time.Millisecond).Add(time.Millisecond / 10)
+	b = t.AppendFormat(b, time.RFC3339Nano)
+	return append(b[:n+prefixLen], b[n+prefixLen+1:]...) // drop the 4th digit
+}
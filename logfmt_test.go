@@ -0,0 +1,128 @@
+package yall_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"github.com/snake-scaly/yall"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogfmt_Append(t *testing.T) {
+	tests := []struct {
+		name string
+		rec  slog.Record
+		want string
+	}{
+		{
+			name: "Empty",
+			rec:  rec(),
+			want: "",
+		},
+		{
+			name: "Flat",
+			rec:  rec("a", "b", "c", 1),
+			want: " a=b c=1",
+		},
+		{
+			name: "Grouped",
+			rec:  rec(slog.Group("g", "x", "y", slog.Group("h", "v", "w")), slog.Group("e"), slog.Group("", "i", "j")),
+			want: " g.x=y g.h.v=w i=j",
+		},
+		{
+			name: "Quoting",
+			rec:  rec("a", "", "b", "x y", "c", "x=y", "d", `x"y`, "e", "x\ny", "f", `x\y`, "g", "ünï"),
+			want: ` a="" b="x y" c="x=y" d="x\"y" e="x\ny" f=x\y g=ünï`,
+		},
+		{
+			name: "Keys",
+			rec:  rec("a b", 1, "c=d", 2, `e"f`, 3, "g\nh", 4, "ключ", 5, "", 6),
+			want: " a_b=1 c_d=2 e_f=3 g_h=4 ключ=5 _=6",
+		},
+		{
+			name: "Values",
+			rec: rec(
+				"t", someTime,
+				"d", 1500*time.Millisecond,
+				"f", 2.5,
+				"err", errors.New("bad thing"),
+				"bytes", []byte("raw"),
+				"lv", testLogValuer{slog.StringValue("resolved")},
+			),
+			want: ` t=2020-11-22T12:34:56.000Z d=1.5s f=2.5 err="bad thing" bytes=raw lv=resolved`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := formatToString(yall.Logfmt{}, nil, tt.rec)
+			assert.Equal(t, tt.want, s)
+		})
+	}
+}
+
+func TestLogfmt_RoundTrip(t *testing.T) {
+	values := []string{
+		"",
+		"plain",
+		"with space",
+		"with=equals",
+		`with "quotes"`,
+		"multi\nline\r\n",
+		"tab\there",
+		"ctl\x01\x7f",
+		`back\slash`,
+		"ünïcödé 日本語",
+		"  ",
+	}
+	for _, v := range values {
+		r := rec("k", v)
+		s := formatToString(yall.Logfmt{}, nil, r)
+		kv := parseLogfmt(t, s)
+		assert.Equal(t, [][2]string{{"k", v}}, kv, "%q", s)
+	}
+}
+
+func TestMessage_QuoteLogfmt(t *testing.T) {
+	r := slog.NewRecord(someTime, slog.LevelInfo, `say "hi"`, 0)
+	s := formatToString(yall.Message{Quote: yall.QuoteLogfmt}, nil, r)
+	assert.Equal(t, `"say \"hi\""`, s)
+}
+
+// parseLogfmt is a minimal logfmt parser following the go-logfmt grammar.
+func parseLogfmt(t *testing.T, s string) (kv [][2]string) {
+	for s != "" {
+		s = strings.TrimLeft(s, " ")
+		eq := strings.IndexByte(s, '=')
+		require.True(t, eq > 0, "missing key in %q", s)
+		key := s[:eq]
+		require.NotContains(t, key, " ")
+		require.NotContains(t, key, `"`)
+		s = s[eq+1:]
+
+		var val string
+		if strings.HasPrefix(s, `"`) {
+			end := 1
+			for ; s[end] != '"'; end++ {
+				if s[end] == '\\' {
+					end++
+				}
+			}
+			require.Nil(t, json.Unmarshal([]byte(s[:end+1]), &val))
+			s = s[end+1:]
+		} else {
+			end := strings.IndexByte(s, ' ')
+			if end == -1 {
+				end = len(s)
+			}
+			val = s[:end]
+			s = s[end:]
+		}
+		kv = append(kv, [2]string{key, val})
+	}
+	return
+}
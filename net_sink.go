@@ -0,0 +1,252 @@
+package yall
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Framing defines how a [NetSink] delimits records in the stream.
+type Framing int
+
+const (
+	FrameNewline = Framing(iota) // Terminate each record with a new line.
+	FrameLength                  // Prefix each record with its length as a 32-bit big-endian integer.
+)
+
+var (
+	errNetSinkClosed = errors.New("yall: net sink is closed")
+	errNotConnected  = errors.New("yall: net sink is not connected")
+)
+
+var (
+	_ Sink    = (*NetSink)(nil)
+	_ Flusher = (*NetSink)(nil)
+	_ Closer  = (*NetSink)(nil)
+)
+
+// NetSink is a sink that sends records formatted by Format to a stream endpoint,
+// such as a TCP input of a log collector. Network and Addr are passed to [net.Dialer],
+// typically "tcp" or "unix". If TLSConfig is not nil, the connection uses TLS.
+//
+// The connection is established on the first Handle. When it fails, or a write fails,
+// the sink waits before reconnecting, doubling the delay after every failed attempt
+// from MinBackoff up to MaxBackoff. Meanwhile records are kept in a buffer and sent
+// after reconnecting. A record may be sent twice if the connection breaks while it is
+// being written. When the buffer is full the oldest records are dropped.
+//
+// Handle blocks for DialTimeout plus WriteTimeout at most, in addition to waiting for
+// concurrent calls of the sink to finish. Errors are reported to OnError rather than
+// returned from Handle, since the record is kept for another attempt.
+type NetSink struct {
+	Network   string
+	Addr      string
+	Level     slog.Leveler
+	Format    Formatter
+	Framing   Framing
+	TLSConfig *tls.Config
+
+	// DialTimeout and WriteTimeout limit the time spent connecting and writing.
+	// The default for both is 10 seconds.
+	DialTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// MinBackoff and MaxBackoff bound the delay before reconnecting.
+	// The defaults are 100 milliseconds and 30 seconds.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// BufferSize is the maximum size in bytes of records kept while disconnected.
+	// The default is 1 MiB.
+	BufferSize int
+
+	// OnError is called with connection and write errors. Errors are discarded
+	// if OnError is nil. OnError is called after the sink is unlocked, so it may use
+	// the sink, e.g. to log the error.
+	OnError func(error)
+
+	dropped atomic.Uint64
+
+	lock        sync.Mutex
+	conn        net.Conn
+	pending     [][]byte
+	pendingSize int
+	failures    int
+	retryAt     time.Time
+	closed      bool
+}
+
+// Dropped returns the number of records dropped due to buffer overflow.
+func (s *NetSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *NetSink) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= s.Level.Level()
+}
+
+// Handle sends the record, or buffers it if the sink is disconnected.
+// Dropping a record is not an error.
+func (s *NetSink) Handle(c context.Context, r slog.Record) error {
+	frame := s.appendFrame(nil, c, r)
+
+	var sendErr error
+	defer func() { s.report(sendErr) }() // runs after unlocking
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return errNetSinkClosed
+	}
+	s.enqueue(frame)
+	sendErr = s.send(false)
+	return nil
+}
+
+// Flush sends the buffered records, reconnecting immediately if the sink is disconnected.
+// It returns the connection error if records remain buffered.
+func (s *NetSink) Flush(context.Context) error {
+	var sendErr error
+	defer func() { s.report(sendErr) }() // runs after unlocking
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return errNetSinkClosed
+	}
+	sendErr = s.send(true)
+	return sendErr
+}
+
+// Close attempts to send the buffered records if the sink is connected and closes
+// the connection. Records which could not be sent are dropped. Handle fails after Close.
+func (s *NetSink) Close(context.Context) error {
+	var sendErr error
+	defer func() { s.report(sendErr) }() // runs after unlocking
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.conn == nil {
+		s.dropped.Add(uint64(len(s.pending)))
+		s.pending = nil
+		return nil
+	}
+	sendErr = s.send(false)
+	err := sendErr
+	s.dropped.Add(uint64(len(s.pending)))
+	s.pending = nil
+	if s.conn != nil {
+		err = errors.Join(err, s.conn.Close())
+		s.conn = nil
+	}
+	return err
+}
+
+func (s *NetSink) appendFrame(b []byte, c context.Context, r slog.Record) []byte {
+	if s.Framing == FrameLength {
+		b = append(b, 0, 0, 0, 0)
+		b = s.Format.Append(b, c, r)
+		binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+		return b
+	}
+	b = s.Format.Append(b, c, r)
+	return append(b, '\n')
+}
+
+func (s *NetSink) enqueue(frame []byte) {
+	s.pending = append(s.pending, frame)
+	s.pendingSize += len(frame)
+	limit := s.BufferSize
+	if limit <= 0 {
+		limit = 1 << 20
+	}
+	for s.pendingSize > limit && len(s.pending) > 1 {
+		s.pendingSize -= len(s.pending[0])
+		s.pending[0] = nil
+		s.pending = s.pending[1:]
+		s.dropped.Add(1)
+	}
+}
+
+// send writes the pending records, connecting first if necessary. Unless force is true,
+// connecting is only attempted after the backoff delay.
+func (s *NetSink) send(force bool) error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	if s.conn == nil {
+		if !force && time.Now().Before(s.retryAt) {
+			return errNotConnected
+		}
+		conn, err := s.dial()
+		if err != nil {
+			s.fail()
+			return err
+		}
+		s.conn = conn
+	}
+	deadline := time.Now().Add(orDefaultDuration(s.WriteTimeout, 10*time.Second))
+	if err := s.conn.SetWriteDeadline(deadline); err != nil {
+		s.fail()
+		return err
+	}
+	for len(s.pending) != 0 {
+		if _, err := s.conn.Write(s.pending[0]); err != nil {
+			s.fail()
+			return err
+		}
+		s.pendingSize -= len(s.pending[0])
+		s.pending[0] = nil
+		s.pending = s.pending[1:]
+	}
+	s.pending = nil
+	s.failures = 0
+	return nil
+}
+
+func (s *NetSink) dial() (net.Conn, error) {
+	d := &net.Dialer{Timeout: orDefaultDuration(s.DialTimeout, 10*time.Second)}
+	if s.TLSConfig != nil {
+		return (&tls.Dialer{NetDialer: d, Config: s.TLSConfig}).Dial(s.Network, s.Addr)
+	}
+	return d.Dial(s.Network, s.Addr)
+}
+
+// fail closes the connection after an error and schedules the next connection attempt.
+// The error is reported by the caller of send once the sink is unlocked.
+func (s *NetSink) fail() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	delay := orDefaultDuration(s.MinBackoff, 100*time.Millisecond)
+	maxDelay := orDefaultDuration(s.MaxBackoff, 30*time.Second)
+	for i := 0; i < s.failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	s.failures++
+	s.retryAt = time.Now().Add(min(delay, maxDelay))
+}
+
+// report passes an error returned by send to OnError, unless it only means that
+// the sink is waiting to reconnect. It must be called without holding the lock.
+func (s *NetSink) report(err error) {
+	if err != nil && !errors.Is(err, errNotConnected) && s.OnError != nil {
+		s.OnError(err)
+	}
+}
+
+func orDefaultDuration(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
@@ -0,0 +1,213 @@
+package yall_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"path/filepath"
+	"github.com/snake-scaly/yall"
+	"testing"
+	"time"
+)
+
+func TestNetSink_Framing(t *testing.T) {
+	tests := []struct {
+		name    string
+		framing yall.Framing
+		want    string
+	}{
+		{
+			name:    "Newline",
+			framing: yall.FrameNewline,
+			want:    "one\ntwo\n",
+		},
+		{
+			name:    "Length",
+			framing: yall.FrameLength,
+			want:    "\x00\x00\x00\x03one\x00\x00\x00\x03two",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			require.Nil(t, err)
+			defer l.Close()
+			received := acceptAll(l)
+
+			s := &yall.NetSink{Network: "tcp", Addr: l.Addr().String(), Format: yall.Message{}, Framing: tt.framing}
+			assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "one")))
+			assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "two")))
+			assert.Nil(t, s.Close(someCtx))
+
+			assert.Equal(t, tt.want, <-received)
+			assert.NotNil(t, s.Handle(someCtx, msgRec(someTime, "three")))
+		})
+	}
+}
+
+func TestNetSink_Buffer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "socket")
+	var errs []error
+	s := &yall.NetSink{
+		Network:    "unix",
+		Addr:       path,
+		Format:     yall.Message{},
+		BufferSize: 10,
+		MinBackoff: time.Hour,
+		OnError:    func(err error) { errs = append(errs, err) },
+	}
+
+	// Nothing listens yet: records are buffered and only the first one tries to connect.
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "one")))
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "two")))
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "three")))
+	assert.Len(t, errs, 1)
+	assert.Equal(t, uint64(1), s.Dropped())
+	assert.NotNil(t, s.Flush(someCtx))
+	assert.Len(t, errs, 2)
+
+	l, err := net.Listen("unix", path)
+	require.Nil(t, err)
+	defer l.Close()
+	received := acceptAll(l)
+
+	assert.Nil(t, s.Flush(someCtx))
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "four")))
+	assert.Nil(t, s.Close(someCtx))
+	assert.Equal(t, "two\nthree\nfour\n", <-received)
+	assert.Len(t, errs, 2)
+}
+
+func TestNetSink_OnErrorUsesSink(t *testing.T) {
+	var s *yall.NetSink
+	var errs []error
+	s = &yall.NetSink{
+		Network:    "unix",
+		Addr:       filepath.Join(t.TempDir(), "socket"),
+		Format:     yall.Message{},
+		MinBackoff: time.Hour,
+		OnError: func(err error) {
+			errs = append(errs, err)
+			_ = s.Handle(someCtx, msgRec(someTime, err.Error()))
+		},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = s.Handle(someCtx, msgRec(someTime, "one"))
+		_ = s.Flush(someCtx)
+		_ = s.Close(someCtx)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sink deadlocked")
+	}
+	assert.Len(t, errs, 2)
+}
+
+func TestNetSink_Reconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer l.Close()
+
+	s := &yall.NetSink{Network: "tcp", Addr: l.Addr().String(), Format: yall.Message{}, MinBackoff: time.Millisecond}
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "one")))
+	conn, err := l.Accept()
+	require.Nil(t, err)
+	require.Nil(t, conn.Close())
+
+	// Writes may succeed for a while after the peer is gone, but the sink must reconnect eventually.
+	accepted := make(chan net.Conn)
+	go func() {
+		c, _ := l.Accept()
+		accepted <- c
+	}()
+	deadline := time.After(5 * time.Second)
+	for {
+		_ = s.Handle(someCtx, msgRec(someTime, "two"))
+		select {
+		case c := <-accepted:
+			require.NotNil(t, c)
+			_ = c.Close()
+			assert.Nil(t, s.Close(someCtx))
+			return
+		case <-deadline:
+			t.Fatal("sink did not reconnect")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestNetSink_TLS(t *testing.T) {
+	cert, pool := testCertificate(t)
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	require.Nil(t, err)
+	defer l.Close()
+	received := acceptAll(l)
+
+	s := &yall.NetSink{
+		Network:   "tcp",
+		Addr:      l.Addr().String(),
+		Format:    yall.Message{},
+		TLSConfig: &tls.Config{RootCAs: pool, ServerName: "localhost"},
+	}
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "secret")))
+	assert.Nil(t, s.Close(someCtx))
+	assert.Equal(t, "secret\n", <-received)
+}
+
+func TestNetSink_Enabled(t *testing.T) {
+	s := &yall.NetSink{Level: slog.LevelWarn}
+	assert.False(t, s.Enabled(someCtx, slog.LevelInfo))
+	assert.True(t, s.Enabled(someCtx, slog.LevelWarn))
+}
+
+// acceptAll accepts a single connection and returns a channel receiving everything read from it.
+func acceptAll(l net.Listener) <-chan string {
+	ch := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			ch <- err.Error()
+			return
+		}
+		defer conn.Close()
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		b, _ := io.ReadAll(conn)
+		ch <- string(b)
+	}()
+	return ch
+}
+
+// testCertificate returns a self-signed certificate for localhost and a pool trusting it.
+func testCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.Nil(t, err)
+	parsed, err := x509.ParseCertificate(der)
+	require.Nil(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(parsed)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
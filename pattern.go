@@ -0,0 +1,502 @@
+package yall
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// PatternConverter creates a [Formatter] for a conversion word in a pattern.
+// Option is the text in braces following the word, or an empty string if there are none.
+// Inner is the compiled pattern in parentheses following the word and the option,
+// or nil if there are none.
+type PatternConverter func(option string, inner Formatter) (Formatter, error)
+
+// PatternError describes a syntax error in a pattern passed to [ParsePattern].
+type PatternError struct {
+	Pattern string // The pattern being parsed.
+	Column  int    // 1-based column in runes where the error was detected.
+	Msg     string // Description of the error.
+	Err     error  // Error returned by a PatternConverter, if any.
+}
+
+func (e *PatternError) Error() string {
+	return fmt.Sprintf("pattern %q: column %d: %s", e.Pattern, e.Column, e.Msg)
+}
+
+func (e *PatternError) Unwrap() error {
+	return e.Err
+}
+
+// RegisterPattern makes a conversion word available in patterns passed to [ParsePattern].
+// It replaces any converter previously registered for the same word, including built-in ones.
+// Words must start with a letter and consist of letters, digits, and underscores.
+func RegisterPattern(word string, c PatternConverter) {
+	if !isPatternWord(word) {
+		panic(fmt.Sprintf("yall: invalid conversion word %q", word))
+	}
+	patternLock.Lock()
+	defer patternLock.Unlock()
+	patternConverters[word] = c
+}
+
+// MustParsePattern is like [ParsePattern] but panics if the pattern cannot be parsed.
+func MustParsePattern(pattern string) Formatter {
+	f, err := ParsePattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// ParsePattern compiles a log4j-style pattern into a [Layout]. For example,
+//
+//	yall.ParsePattern("%time{2006-01-02} [%level{5}] %msg%attrs{smart}")
+//
+// is equivalent to
+//
+//	yall.Layout{
+//		Format: "%s [%s] %s%s",
+//		Args: []yall.Formatter{
+//			yall.Time{Layout: "2006-01-02"},
+//			yall.Layout{Format: "%5s", Args: []yall.Formatter{yall.Level{}}},
+//			yall.Message{},
+//			yall.TextAttrs{Quote: yall.QuoteSmart},
+//		},
+//	}
+//
+// A conversion has the form
+//
+//	%[-][width]word[{option}][(pattern)]
+//
+// Width pads the result with spaces to the given number of bytes, on the left,
+// or on the right if preceded by a minus. Use pad for padding to a number of display
+// cells instead. The option is passed to the converter
+// as is. The pattern in parentheses is compiled recursively and passed to the converter
+// as the inner formatter. Use %% for a literal percent sign, and %( and %) for
+// literal parentheses inside parentheses.
+//
+// The built-in conversion words are:
+//
+//   - time{layout} is [Time] with the given layout.
+//   - level{options} is [Level] with a comma-separated list of options: a width to pad
+//     the level to like a width modifier, extended to use [ExtendedLevelNames],
+//     upper, lower, or title to set [LetterCase], and char or three to set [LevelAbbrev].
+//   - msg{quote} and message{quote} are [Message] with the given [QuoteType]: one of
+//     never, always, smart, json, or logfmt.
+//   - source{options} is [Source] with a comma-separated list of options: short or
+//     relative to set Short or Relative, and func, qualified, or fullfunc to add
+//     the function name in the form of [FuncShort], [FuncQualified], or [FuncFull].
+//   - attrs{quote} is [TextAttrs] with the given [QuoteType].
+//   - attr{key,quote} is [Attr] with the given key and optional [QuoteType].
+//   - logfmt is [Logfmt].
+//   - json{source} is [JSON], optionally with AddSource.
+//   - jsonattrs{members} is [JSONAttrs], optionally with Members.
+//   - color{colors}(pattern) is [Colorize] with a comma-separated list of colors:
+//     bold, dim, italic, underline, red, green, yellow, blue, magenta, cyan, white,
+//     gray, or numeric SGR parameters.
+//   - highlight(pattern) is [Colorize] with [DefaultLevelColors].
+//   - cond(pattern) is [Conditional]. The pattern must contain exactly one conversion.
+//   - pad{width}(pattern) is [Pad] which aligns right like a width modifier,
+//     left if width is preceded by a minus, or in the center if preceded by a caret.
+//   - truncate{width,marker}(pattern) is [Truncate] with an optional marker.
+//     A negative width keeps the end of the output.
+//
+// More conversion words can be added with [RegisterPattern].
+func ParsePattern(pattern string) (Formatter, error) {
+	p := patternParser{pattern: pattern}
+	l, err := p.parse(false)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+var (
+	patternLock       sync.RWMutex
+	patternConverters = map[string]PatternConverter{
+		"time":      convertTime,
+		"level":     convertLevel,
+		"msg":       convertMessage,
+		"message":   convertMessage,
+		"source":    convertSource,
+		"attrs":     convertAttrs,
+		"attr":      convertAttr,
+		"logfmt":    convertLogfmt,
+		"json":      convertJSON,
+		"jsonattrs": convertJSONAttrs,
+		"color":     convertColor,
+		"highlight": convertHighlight,
+		"cond":      convertConditional,
+		"pad":       convertPad,
+		"truncate":  convertTruncate,
+	}
+)
+
+type patternParser struct {
+	pattern string
+	pos     int
+}
+
+// parse compiles the pattern up to the end of input or, if nested is true,
+// up to a closing parenthesis.
+func (p *patternParser) parse(nested bool) (Layout, error) {
+	var format strings.Builder
+	var args []Formatter
+	start := p.pos
+
+	for p.pos < len(p.pattern) {
+		c := p.pattern[p.pos]
+		switch {
+		case c == ')' && nested:
+			return Layout{Format: format.String(), Args: args}, nil
+		case c != '%':
+			format.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		convStart := p.pos
+		p.pos++
+		if p.pos == len(p.pattern) {
+			return Layout{}, p.errorAt(convStart, "incomplete conversion", nil)
+		}
+		switch p.pattern[p.pos] {
+		case '%':
+			format.WriteString("%%")
+			p.pos++
+			continue
+		case '(', ')':
+			format.WriteByte(p.pattern[p.pos])
+			p.pos++
+			continue
+		}
+
+		verb, err := p.parseWidth()
+		if err != nil {
+			return Layout{}, err
+		}
+		f, err := p.parseConversion()
+		if err != nil {
+			return Layout{}, err
+		}
+		format.WriteString(verb)
+		args = append(args, f)
+	}
+
+	if nested {
+		return Layout{}, p.errorAt(start-1, "missing closing parenthesis", nil)
+	}
+	return Layout{Format: format.String(), Args: args}, nil
+}
+
+// parseWidth parses an optional width modifier and returns the matching fmt verb.
+func (p *patternParser) parseWidth() (string, error) {
+	start := p.pos
+	if p.pos < len(p.pattern) && p.pattern[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.pattern) && isDigit(p.pattern[p.pos]) {
+		p.pos++
+	}
+	w := p.pattern[start:p.pos]
+	if w == "-" {
+		return "", p.errorAt(start, "missing width after '-'", nil)
+	}
+	return "%" + w + "s", nil
+}
+
+func (p *patternParser) parseConversion() (Formatter, error) {
+	start := p.pos
+	for p.pos < len(p.pattern) && isWordByte(p.pattern[p.pos], p.pos == start) {
+		p.pos++
+	}
+	word := p.pattern[start:p.pos]
+	if word == "" {
+		return nil, p.errorAt(start, "missing conversion word", nil)
+	}
+
+	patternLock.RLock()
+	conv, ok := patternConverters[word]
+	patternLock.RUnlock()
+	if !ok {
+		return nil, p.errorAt(start, fmt.Sprintf("unknown conversion word %q", word), nil)
+	}
+
+	var option string
+	if p.pos < len(p.pattern) && p.pattern[p.pos] == '{' {
+		end := strings.IndexByte(p.pattern[p.pos:], '}')
+		if end == -1 {
+			return nil, p.errorAt(p.pos, "missing closing brace", nil)
+		}
+		option = p.pattern[p.pos+1 : p.pos+end]
+		p.pos += end + 1
+	}
+
+	var inner Formatter
+	if p.pos < len(p.pattern) && p.pattern[p.pos] == '(' {
+		p.pos++
+		l, err := p.parse(true)
+		if err != nil {
+			return nil, err
+		}
+		p.pos++ // closing parenthesis
+		inner = l
+	}
+
+	f, err := conv(option, inner)
+	if err != nil {
+		return nil, p.errorAt(start, fmt.Sprintf("%s: %v", word, err), err)
+	}
+	return f, nil
+}
+
+func (p *patternParser) errorAt(pos int, msg string, err error) error {
+	return &PatternError{
+		Pattern: p.pattern,
+		Column:  utf8.RuneCountInString(p.pattern[:pos]) + 1,
+		Msg:     msg,
+		Err:     err,
+	}
+}
+
+func isPatternWord(w string) bool {
+	if w == "" {
+		return false
+	}
+	for i := 0; i < len(w); i++ {
+		if !isWordByte(w[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordByte(c byte, first bool) bool {
+	if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' {
+		return true
+	}
+	return !first && (c == '_' || isDigit(c))
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func convertTime(option string, _ Formatter) (Formatter, error) {
+	return Time{Layout: option}, nil
+}
+
+func convertLevel(option string, _ Formatter) (Formatter, error) {
+	var names LevelNames
+	named := false
+	width := ""
+	for _, o := range strings.Split(option, ",") {
+		switch o {
+		case "":
+		case "extended":
+			names.Exact, named = ExtendedLevelNames().Exact, true
+		case "upper":
+			names.Case, named = CaseUpper, true
+		case "lower":
+			names.Case, named = CaseLower, true
+		case "title":
+			names.Case, named = CaseTitle, true
+		case "char":
+			names.Abbrev, named = AbbrevChar, true
+		case "three":
+			names.Abbrev, named = AbbrevThree, true
+		default:
+			if _, err := strconv.Atoi(o); err != nil {
+				return nil, fmt.Errorf("invalid option %q", o)
+			}
+			width = o
+		}
+	}
+	var l Level
+	if named {
+		l.Names = &names
+	}
+	if width == "" {
+		return l, nil
+	}
+	return Layout{Format: "%" + width + "s", Args: []Formatter{l}}, nil
+}
+
+func convertMessage(option string, _ Formatter) (Formatter, error) {
+	q, err := parseQuoteType(option)
+	return Message{Quote: q}, err
+}
+
+func convertSource(option string, _ Formatter) (Formatter, error) {
+	var src Source
+	for _, o := range strings.Split(option, ",") {
+		switch o {
+		case "":
+		case "short":
+			src.Short = true
+		case "relative":
+			src.Relative = true
+		case "func":
+			src.Function = FuncShort
+		case "qualified":
+			src.Function = FuncQualified
+		case "fullfunc":
+			src.Function = FuncFull
+		default:
+			return nil, fmt.Errorf("invalid option %q", o)
+		}
+	}
+	return src, nil
+}
+
+func convertAttrs(option string, _ Formatter) (Formatter, error) {
+	q, err := parseQuoteType(option)
+	return TextAttrs{Quote: q}, err
+}
+
+func convertAttr(option string, _ Formatter) (Formatter, error) {
+	key, qs, _ := strings.Cut(option, ",")
+	if key == "" {
+		return nil, errors.New("missing key")
+	}
+	q, err := parseQuoteType(qs)
+	return Attr{Key: key, Quote: q}, err
+}
+
+func convertLogfmt(option string, _ Formatter) (Formatter, error) {
+	if option != "" {
+		return nil, fmt.Errorf("invalid option %q", option)
+	}
+	return Logfmt{}, nil
+}
+
+func convertJSON(option string, _ Formatter) (Formatter, error) {
+	switch option {
+	case "":
+		return JSON{}, nil
+	case "source":
+		return JSON{AddSource: true}, nil
+	}
+	return nil, fmt.Errorf("invalid option %q", option)
+}
+
+func convertJSONAttrs(option string, _ Formatter) (Formatter, error) {
+	switch option {
+	case "":
+		return JSONAttrs{}, nil
+	case "members":
+		return JSONAttrs{Members: true}, nil
+	}
+	return nil, fmt.Errorf("invalid option %q", option)
+}
+
+func convertColor(option string, inner Formatter) (Formatter, error) {
+	if inner == nil {
+		return nil, errMissingInner
+	}
+	if option == "" {
+		return nil, errors.New("missing color")
+	}
+	var codes []string
+	for _, name := range strings.Split(option, ",") {
+		name = strings.TrimSpace(name)
+		c, ok := colorNames[name]
+		if !ok {
+			if _, err := strconv.Atoi(name); err != nil {
+				return nil, fmt.Errorf("unknown color %q", name)
+			}
+			c = Color(name)
+		}
+		codes = append(codes, string(c))
+	}
+	return Colorize{Inner: inner, Color: Color(strings.Join(codes, ";"))}, nil
+}
+
+func convertHighlight(_ string, inner Formatter) (Formatter, error) {
+	if inner == nil {
+		return nil, errMissingInner
+	}
+	return Colorize{Inner: inner, Levels: DefaultLevelColors()}, nil
+}
+
+func convertConditional(_ string, inner Formatter) (Formatter, error) {
+	if inner == nil {
+		return nil, errMissingInner
+	}
+	l := inner.(Layout)
+	if len(l.Args) != 1 {
+		return nil, errors.New("exactly one conversion expected")
+	}
+	return Conditional{Format: l.Format, Inner: l.Args[0]}, nil
+}
+
+var errMissingInner = errors.New("missing pattern in parentheses")
+
+var colorNames = map[string]Color{
+	"bold":      ColorBold,
+	"dim":       ColorDim,
+	"italic":    ColorItalic,
+	"underline": ColorUnderline,
+	"red":       ColorRed,
+	"green":     ColorGreen,
+	"yellow":    ColorYellow,
+	"blue":      ColorBlue,
+	"magenta":   ColorMagenta,
+	"cyan":      ColorCyan,
+	"white":     ColorWhite,
+	"gray":      ColorGray,
+}
+
+func convertPad(option string, inner Formatter) (Formatter, error) {
+	if inner == nil {
+		return nil, errMissingInner
+	}
+	p := Pad{Inner: inner, Align: AlignRight}
+	if w, ok := strings.CutPrefix(option, "-"); ok {
+		p.Align, option = AlignLeft, w
+	} else if w, ok := strings.CutPrefix(option, "^"); ok {
+		p.Align, option = AlignCenter, w
+	}
+	w, err := strconv.Atoi(option)
+	if err != nil || w < 0 {
+		return nil, fmt.Errorf("invalid width %q", option)
+	}
+	p.Width = w
+	return p, nil
+}
+
+func convertTruncate(option string, inner Formatter) (Formatter, error) {
+	if inner == nil {
+		return nil, errMissingInner
+	}
+	ws, marker, _ := strings.Cut(option, ",")
+	w, err := strconv.Atoi(ws)
+	if err != nil {
+		return nil, fmt.Errorf("invalid width %q", ws)
+	}
+	t := Truncate{Inner: inner, Width: w, Marker: marker}
+	if w < 0 {
+		t.Width, t.KeepEnd = -w, true
+	}
+	return t, nil
+}
+
+func parseQuoteType(s string) (QuoteType, error) {
+	switch s {
+	case "", "never":
+		return QuoteNever, nil
+	case "always":
+		return QuoteAlways, nil
+	case "smart":
+		return QuoteSmart, nil
+	case "json":
+		return QuoteJSON, nil
+	case "logfmt":
+		return QuoteLogfmt, nil
+	}
+	return 0, fmt.Errorf("invalid quote type %q", s)
+}
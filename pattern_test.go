@@ -0,0 +1,253 @@
+package yall_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"github.com/snake-scaly/yall"
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    yall.Formatter
+	}{
+		{
+			name:    "Empty",
+			pattern: "",
+			want:    yall.Layout{},
+		},
+		{
+			name:    "Literal",
+			pattern: "a (b) %% c",
+			want:    yall.Layout{Format: "a (b) %% c"},
+		},
+		{
+			name:    "Example",
+			pattern: "%time{2006-01-02} [%level{5}] %msg%attrs{smart}",
+			want: yall.Layout{
+				Format: "%s [%s] %s%s",
+				Args: []yall.Formatter{
+					yall.Time{Layout: "2006-01-02"},
+					yall.Layout{Format: "%5s", Args: []yall.Formatter{yall.Level{}}},
+					yall.Message{},
+					yall.TextAttrs{Quote: yall.QuoteSmart},
+				},
+			},
+		},
+		{
+			name:    "Width",
+			pattern: "%-5level|%8source{short}",
+			want: yall.Layout{
+				Format: "%-5s|%8s",
+				Args:   []yall.Formatter{yall.Level{}, yall.Source{Short: true}},
+			},
+		},
+		{
+			name:    "Conditional",
+			pattern: "%message{json}%cond(%(%-3attrs%))",
+			want: yall.Layout{
+				Format: "%s%s",
+				Args: []yall.Formatter{
+					yall.Message{Quote: yall.QuoteJSON},
+					yall.Conditional{Format: "(%-3s)", Inner: yall.TextAttrs{}},
+				},
+			},
+		},
+		{
+			name:    "Color",
+			pattern: "%color{bold,red,4}(%msg)%highlight(%level)",
+			want: yall.Layout{
+				Format: "%s%s",
+				Args: []yall.Formatter{
+					yall.Colorize{
+						Inner: yall.Layout{Format: "%s", Args: []yall.Formatter{yall.Message{}}},
+						Color: "1;31;4",
+					},
+					yall.Colorize{
+						Inner:  yall.Layout{Format: "%s", Args: []yall.Formatter{yall.Level{}}},
+						Levels: yall.DefaultLevelColors(),
+					},
+				},
+			},
+		},
+		{
+			name:    "LevelOptions",
+			pattern: "%level{extended,three,lower}|%level{char,4}",
+			want: yall.Layout{
+				Format: "%s|%s",
+				Args: []yall.Formatter{
+					yall.Level{Names: &yall.LevelNames{
+						Exact:  yall.ExtendedLevelNames().Exact,
+						Case:   yall.CaseLower,
+						Abbrev: yall.AbbrevThree,
+					}},
+					yall.Layout{Format: "%4s", Args: []yall.Formatter{yall.Level{Names: &yall.LevelNames{Abbrev: yall.AbbrevChar}}}},
+				},
+			},
+		},
+		{
+			name:    "Attr",
+			pattern: "[%attr{req.id}] [%attr{user,smart}]",
+			want: yall.Layout{
+				Format: "[%s] [%s]",
+				Args: []yall.Formatter{
+					yall.Attr{Key: "req.id"},
+					yall.Attr{Key: "user", Quote: yall.QuoteSmart},
+				},
+			},
+		},
+		{
+			name:    "PadTruncate",
+			pattern: "%pad{-8}(%level)%pad{^3}(%msg)%truncate{10,…}(%msg)%truncate{-5}(%source)",
+			want: yall.Layout{
+				Format: "%s%s%s%s",
+				Args: []yall.Formatter{
+					yall.Pad{Inner: yall.Layout{Format: "%s", Args: []yall.Formatter{yall.Level{}}}, Width: 8, Align: yall.AlignLeft},
+					yall.Pad{Inner: yall.Layout{Format: "%s", Args: []yall.Formatter{yall.Message{}}}, Width: 3, Align: yall.AlignCenter},
+					yall.Truncate{Inner: yall.Layout{Format: "%s", Args: []yall.Formatter{yall.Message{}}}, Width: 10, Marker: "…"},
+					yall.Truncate{Inner: yall.Layout{Format: "%s", Args: []yall.Formatter{yall.Source{}}}, Width: 5, KeepEnd: true},
+				},
+			},
+		},
+		{
+			name:    "Structured",
+			pattern: "%json{source}%logfmt%jsonattrs{members}",
+			want: yall.Layout{
+				Format: "%s%s%s",
+				Args: []yall.Formatter{
+					yall.JSON{AddSource: true},
+					yall.Logfmt{},
+					yall.JSONAttrs{Members: true},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := yall.ParsePattern(tt.pattern)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, f)
+		})
+	}
+}
+
+func TestParsePattern_Format(t *testing.T) {
+	f := yall.MustParsePattern("%time{15:04:05} [%5level] %msg%cond(:%attrs{smart})")
+	r := rec("a", "b c")
+	s := formatToString(f, nil, r)
+	assert.Equal(t, `12:34:56 [ INFO] msg: a="b c"`, s)
+}
+
+func TestParsePattern_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		column  int
+		msg     string
+	}{
+		{
+			name:    "Incomplete",
+			pattern: "abc%",
+			column:  4,
+			msg:     "incomplete conversion",
+		},
+		{
+			name:    "MissingWord",
+			pattern: "ab%5 x",
+			column:  5,
+			msg:     "missing conversion word",
+		},
+		{
+			name:    "MissingWidth",
+			pattern: "%-level",
+			column:  2,
+			msg:     "missing width after '-'",
+		},
+		{
+			name:    "UnknownWord",
+			pattern: "ü %nope",
+			column:  4,
+			msg:     `unknown conversion word "nope"`,
+		},
+		{
+			name:    "MissingBrace",
+			pattern: "%time{15:04",
+			column:  6,
+			msg:     "missing closing brace",
+		},
+		{
+			name:    "MissingParen",
+			pattern: "%cond(:%msg",
+			column:  6,
+			msg:     "missing closing parenthesis",
+		},
+		{
+			name:    "BadOption",
+			pattern: "%msg %attrs{sometimes}",
+			column:  7,
+			msg:     `attrs: invalid quote type "sometimes"`,
+		},
+		{
+			name:    "MissingKey",
+			pattern: "%attr",
+			column:  2,
+			msg:     "attr: missing key",
+		},
+		{
+			name:    "BadPadWidth",
+			pattern: "%pad{x}(%msg)",
+			column:  2,
+			msg:     `pad: invalid width "x"`,
+		},
+		{
+			name:    "MissingInner",
+			pattern: "%color{red}",
+			column:  2,
+			msg:     "color: missing pattern in parentheses",
+		},
+		{
+			name:    "CondTwoArgs",
+			pattern: "%cond(%msg%level)",
+			column:  2,
+			msg:     "cond: exactly one conversion expected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := yall.ParsePattern(tt.pattern)
+			assert.Nil(t, f)
+			var pe *yall.PatternError
+			if assert.True(t, errors.As(err, &pe)) {
+				assert.Equal(t, tt.pattern, pe.Pattern)
+				assert.Equal(t, tt.column, pe.Column)
+				assert.Equal(t, tt.msg, pe.Msg)
+			}
+		})
+	}
+}
+
+func TestRegisterPattern(t *testing.T) {
+	errBad := errors.New("bad")
+	yall.RegisterPattern("test_upper", func(option string, _ yall.Formatter) (yall.Formatter, error) {
+		if option == "bad" {
+			return nil, errBad
+		}
+		return testFormatter{"UP" + option}, nil
+	})
+
+	f, err := yall.ParsePattern("<%test_upper{x}>")
+	assert.Nil(t, err)
+	assert.Equal(t, "<UPx>", formatToString(f, nil, slog.Record{}))
+
+	_, err = yall.ParsePattern("%test_upper{bad}")
+	assert.ErrorIs(t, err, errBad)
+
+	assert.Panics(t, func() {
+		yall.RegisterPattern("1abc", nil)
+	})
+}
@@ -2,9 +2,12 @@ package yall
 
 import (
 	"context"
+	"errors"
 	"io"
 	"log/slog"
+	"os"
 	"sync"
+	"sync/atomic"
 )
 
 // Sink receives complete logging events.
@@ -16,16 +19,61 @@ type Sink interface {
 	Handle(c context.Context, r slog.Record) error
 }
 
-var _ Sink = (*WriterSink)(nil)
+// Flusher is implemented by sinks which can deliver buffered records on demand.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Closer is implemented by sinks which hold resources that must be released.
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// Flush flushes s if it implements [Flusher], and returns nil otherwise.
+// Handlers created by [NewHandler] implement Flusher by flushing their sink, so
+// Flush can be called with [slog.Logger.Handler] to flush the whole tree of sinks.
+func Flush(ctx context.Context, s Sink) error {
+	if f, ok := s.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// Close closes s if it implements [Closer], and returns nil otherwise.
+// Handlers created by [NewHandler] implement Closer by closing their sink, so
+// Close can be called with [slog.Logger.Handler] to close the whole tree of sinks.
+func Close(ctx context.Context, s Sink) error {
+	if c, ok := s.(Closer); ok {
+		return c.Close(ctx)
+	}
+	return nil
+}
+
+var (
+	_ Sink    = (*WriterSink)(nil)
+	_ Flusher = (*WriterSink)(nil)
+	_ Closer  = (*WriterSink)(nil)
+)
 
 // WriterSink is a sink that writes logs to an io.Writer.
 // Each log event is terminated with a new line and is written as a single write on the Writer.
+//
+// Color controls whether colors are enabled for the Format, see [WithColor].
+// The default [ColorAuto] only enables colors if the Writer is a terminal and the NO_COLOR
+// environment variable is not set. The decision is made once, on the first Handle.
+//
+// Flush calls the Flush method of the Writer if it has one, e.g. [bufio.Writer].
+// Close closes the Writer if it implements [io.Closer], unless it is [os.Stdout]
+// or [os.Stderr].
 type WriterSink struct {
-	Writer io.Writer
-	Level  slog.Leveler
-	Format Formatter
-	buffer []byte
-	lock   sync.Mutex
+	Writer    io.Writer
+	Level     slog.Leveler
+	Format    Formatter
+	Color     ColorMode
+	buffer    []byte
+	lock      sync.Mutex
+	colorOnce sync.Once
+	color     bool
 }
 
 func (s *WriterSink) Enabled(_ context.Context, l slog.Level) bool {
@@ -35,8 +83,70 @@ func (s *WriterSink) Enabled(_ context.Context, l slog.Level) bool {
 func (s *WriterSink) Handle(c context.Context, r slog.Record) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
+	s.colorOnce.Do(func() {
+		s.color = useColor(s.Writer, s.Color)
+	})
+	if ColorEnabled(c) != s.color {
+		c = WithColor(c, s.color)
+	}
 	s.buffer = s.Format.Append(s.buffer[:0], c, r)
 	s.buffer = append(s.buffer, '\n')
 	_, err := s.Writer.Write(s.buffer)
 	return err
 }
+
+func (s *WriterSink) Flush(context.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if f, ok := s.Writer.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (s *WriterSink) Close(context.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var err error
+	if f, ok := s.Writer.(interface{ Flush() error }); ok {
+		err = f.Flush()
+	}
+	if c, ok := s.Writer.(io.Closer); ok && c != os.Stdout && c != os.Stderr {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
+
+// taskGroup runs background tasks and waits for them to finish.
+type taskGroup struct {
+	wg      sync.WaitGroup
+	pending atomic.Int64
+}
+
+func (g *taskGroup) Go(f func()) {
+	g.pending.Add(1)
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer g.pending.Add(-1)
+		f()
+	}()
+}
+
+// Wait waits until all tasks finish or ctx is done. If ctx is done first, Wait returns ctx.Err().
+func (g *taskGroup) Wait(ctx context.Context) error {
+	if g.pending.Load() == 0 {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
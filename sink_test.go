@@ -0,0 +1,86 @@
+package yall_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"os"
+	"github.com/snake-scaly/yall"
+	"testing"
+)
+
+func TestWriterSink_Handle(t *testing.T) {
+	buf := bytes.Buffer{}
+	s := yall.WriterSink{Writer: &buf, Level: slog.LevelInfo, Format: yall.Message{}}
+	assert.True(t, s.Enabled(someCtx, slog.LevelInfo))
+	assert.False(t, s.Enabled(someCtx, slog.LevelDebug))
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "a")))
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "b")))
+	assert.Equal(t, "a\nb\n", buf.String())
+}
+
+func TestWriterSink_Flush(t *testing.T) {
+	buf := bytes.Buffer{}
+	w := bufio.NewWriter(&buf)
+	s := yall.WriterSink{Writer: w, Level: slog.LevelInfo, Format: yall.Message{}}
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "a")))
+	assert.Equal(t, "", buf.String())
+	assert.Nil(t, s.Flush(someCtx))
+	assert.Equal(t, "a\n", buf.String())
+}
+
+func TestWriterSink_Close(t *testing.T) {
+	w := &closeWriter{}
+	s := yall.WriterSink{Writer: w, Level: slog.LevelInfo, Format: yall.Message{}}
+	assert.Nil(t, s.Close(someCtx))
+	assert.True(t, w.closed)
+
+	s = yall.WriterSink{Writer: os.Stderr, Level: slog.LevelInfo, Format: yall.Message{}}
+	assert.Nil(t, s.Close(someCtx))
+	_, err := os.Stderr.Stat()
+	assert.Nil(t, err)
+}
+
+func TestFlushClose(t *testing.T) {
+	e := errors.New("e")
+	s := &lifecycleSink{err: e}
+
+	assert.ErrorIs(t, yall.Flush(someCtx, s), e)
+	assert.ErrorIs(t, yall.Close(someCtx, s), e)
+	assert.Equal(t, 1, s.flushes)
+	assert.Equal(t, 1, s.closes)
+
+	assert.Nil(t, yall.Flush(someCtx, &testSink{}))
+	assert.Nil(t, yall.Close(someCtx, &testSink{}))
+}
+
+type closeWriter struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (w *closeWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+// lifecycleSink is a testSink implementing Flusher and Closer.
+type lifecycleSink struct {
+	testSink
+	err     error
+	flushes int
+	closes  int
+}
+
+func (s *lifecycleSink) Flush(context.Context) error {
+	s.flushes++
+	return s.err
+}
+
+func (s *lifecycleSink) Close(context.Context) error {
+	s.closes++
+	return s.err
+}
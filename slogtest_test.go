@@ -0,0 +1,93 @@
+package yall_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"github.com/snake-scaly/yall"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+)
+
+func TestSlogtest_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	newHandler := func(*testing.T) slog.Handler {
+		buf.Reset()
+		return yall.NewHandler(&yall.WriterSink{Writer: &buf, Level: slog.LevelDebug, Format: yall.JSON{}})
+	}
+	result := func(t *testing.T) map[string]any {
+		m := map[string]any{}
+		if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	slogtest.Run(t, newHandler, result)
+}
+
+func TestSlogtest_Text(t *testing.T) {
+	var buf bytes.Buffer
+	format := yall.Layout{
+		Format: "%slevel=%s msg=%s%s",
+		Args: []yall.Formatter{
+			yall.Conditional{Format: "time=%s ", Inner: yall.Time{Layout: time.RFC3339Nano}},
+			yall.Level{},
+			yall.Message{Quote: yall.QuoteSmart},
+			yall.TextAttrs{Quote: yall.QuoteSmart},
+		},
+	}
+	newHandler := func(*testing.T) slog.Handler {
+		buf.Reset()
+		return yall.NewHandler(&yall.WriterSink{Writer: &buf, Level: slog.LevelDebug, Format: format})
+	}
+	result := func(t *testing.T) map[string]any {
+		m, err := parseText(strings.TrimSuffix(buf.String(), "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	slogtest.Run(t, newHandler, result)
+}
+
+// parseText parses space-separated key=value pairs with optionally quoted values.
+// Dotted keys become nested maps.
+func parseText(s string) (map[string]any, error) {
+	m := map[string]any{}
+	for s != "" {
+		k, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("missing '=' in %q", s)
+		}
+		var v string
+		if strings.HasPrefix(rest, `"`) {
+			q, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, err
+			}
+			v, _ = strconv.Unquote(q)
+			rest = rest[len(q):]
+		} else {
+			v, rest, _ = strings.Cut(rest, " ")
+			rest = " " + rest
+		}
+		s = strings.TrimPrefix(rest, " ")
+
+		keys := strings.Split(k, ".")
+		g := m
+		for _, kk := range keys[:len(keys)-1] {
+			sub, ok := g[kk].(map[string]any)
+			if !ok {
+				sub = map[string]any{}
+				g[kk] = sub
+			}
+			g = sub
+		}
+		g[keys[len(keys)-1]] = v
+	}
+	return m, nil
+}
@@ -0,0 +1,120 @@
+package yall
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FuncName defines how [Source] formats function names.
+type FuncName int
+
+const (
+	FuncNone      = FuncName(iota) // Omit the function name.
+	FuncShort                      // Function name without the package, e.g. "(*Server).Serve".
+	FuncQualified                  // Function name qualified with the package name, e.g. "http.(*Server).Serve".
+	FuncFull                       // Function name qualified with the package path, e.g. "net/http.(*Server).Serve".
+)
+
+// Source is a [Formatter] that formats the source location of [slog.Record.PC]
+// as "file:line", followed by a space and the function name if Function is set.
+// Inlined functions are reported correctly.
+//
+// The file is formatted as the full path by default. Set Short to true to only print
+// the source file name without path, Relative to print the path relative to the working
+// directory of the process, which many terminals and editors make clickable, or TrimPrefix
+// to remove a prefix such as the module root from the path. If the path doesn't start
+// with TrimPrefix or cannot be made relative, the full path is printed.
+//
+// Unknown is printed when the location is not available, e.g. if PC is zero.
+// An empty Unknown can be combined with [Conditional].
+type Source struct {
+	Short      bool
+	Relative   bool
+	TrimPrefix string
+	Function   FuncName
+	Unknown    string
+}
+
+func (s Source) Append(b []byte, _ context.Context, r slog.Record) []byte {
+	if r.PC == 0 {
+		return append(b, s.Unknown...)
+	}
+	src := recordSource(r.PC)
+	if src.File == "" {
+		return append(b, s.Unknown...)
+	}
+	b = append(b, s.file(src.File)...)
+	b = append(b, ':')
+	b = strconv.AppendInt(b, int64(src.Line), 10)
+	if fn := funcName(src.Function, s.Function); fn != "" {
+		b = append(b, ' ')
+		b = append(b, fn...)
+	}
+	return b
+}
+
+// group returns the source location of pc in the shape of [slog.Source].
+// The function is full unless Function is set.
+func (s Source) group(pc uintptr) slog.Value {
+	src := recordSource(pc)
+	var as []slog.Attr
+	fn := src.Function
+	if s.Function != FuncNone {
+		fn = funcName(fn, s.Function)
+	}
+	if fn != "" {
+		as = append(as, slog.String("function", fn))
+	}
+	if src.File != "" {
+		as = append(as, slog.String("file", s.file(src.File)))
+	}
+	if src.Line != 0 {
+		as = append(as, slog.Int("line", src.Line))
+	}
+	return slog.GroupValue(as...)
+}
+
+func (s Source) file(f string) string {
+	switch {
+	case s.Short:
+		return path.Base(f)
+	case s.Relative:
+		if wd := workingDir(); wd != "" {
+			if rel, err := filepath.Rel(wd, filepath.FromSlash(f)); err == nil {
+				return rel
+			}
+		}
+	case s.TrimPrefix != "":
+		if rest, ok := strings.CutPrefix(f, s.TrimPrefix); ok {
+			return strings.TrimPrefix(rest, "/")
+		}
+	}
+	return f
+}
+
+// funcName formats the full function name fn according to n.
+func funcName(fn string, n FuncName) string {
+	switch n {
+	case FuncNone:
+		return ""
+	case FuncFull:
+		return fn
+	}
+	// The package path may contain dots, but not after the last slash.
+	qualified := fn[strings.LastIndexByte(fn, '/')+1:]
+	if n == FuncQualified {
+		return qualified
+	}
+	return qualified[strings.IndexByte(qualified, '.')+1:]
+}
+
+var workingDir = sync.OnceValue(func() string {
+	wd, _ := os.Getwd()
+	return wd
+})
@@ -0,0 +1,115 @@
+package yall_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"os"
+	"github.com/snake-scaly/yall"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSource_Options(t *testing.T) {
+	wd, _ := os.Getwd()
+
+	tests := []struct {
+		name string
+		src  yall.Source
+		rec  slog.Record
+		want string
+	}{
+		{
+			name: "Short",
+			src:  yall.Source{Short: true},
+			rec:  rec(),
+			want: "util_test.go:14",
+		},
+		{
+			name: "Relative",
+			src:  yall.Source{Relative: true},
+			rec:  rec(),
+			want: "util_test.go:14",
+		},
+		{
+			name: "TrimPrefix",
+			src:  yall.Source{TrimPrefix: wd},
+			rec:  rec(),
+			want: "util_test.go:14",
+		},
+		{
+			name: "TrimPrefixMismatch",
+			src:  yall.Source{TrimPrefix: "/nowhere", Short: true},
+			rec:  rec(),
+			want: "util_test.go:14",
+		},
+		{
+			name: "FuncShort",
+			src:  yall.Source{Short: true, Function: yall.FuncShort},
+			rec:  rec(),
+			want: "util_test.go:14 rec",
+		},
+		{
+			name: "FuncQualified",
+			src:  yall.Source{Short: true, Function: yall.FuncQualified},
+			rec:  rec(),
+			want: "util_test.go:14 yall_test.rec",
+		},
+		{
+			name: "FuncFull",
+			src:  yall.Source{Short: true, Function: yall.FuncFull},
+			rec:  rec(),
+			want: "util_test.go:14 github.com/snake-scaly/yall_test.rec",
+		},
+		{
+			name: "Method",
+			src:  yall.Source{Short: true, Function: yall.FuncShort},
+			rec:  pcRecord(testPC{}.pc()),
+			want: "source_test.go:107 testPC.pc",
+		},
+		{
+			name: "Inlined",
+			src:  yall.Source{Short: true, Function: yall.FuncShort},
+			rec:  pcRecord(inlinedPC()),
+			want: "source_test.go:113 inlinedPC",
+		},
+		{
+			name: "Unknown",
+			src:  yall.Source{Unknown: "???"},
+			rec:  slog.NewRecord(someTime, slog.LevelInfo, "msg", 0),
+			want: "???",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := formatToString(tt.src, nil, tt.rec)
+			assert.Equal(t, tt.want, s)
+		})
+	}
+}
+
+func TestJSON_SourceOptions(t *testing.T) {
+	f := yall.JSON{AddSource: true, SourceOptions: yall.Source{Short: true, Function: yall.FuncShort}}
+	r := rec()
+	r.Time = time.Time{}
+	s := formatToString(f, nil, r)
+	assert.Equal(t, `{"level":"INFO","source":{"function":"rec","file":"util_test.go","line":14},"msg":"msg"}`, s)
+}
+
+func pcRecord(pc uintptr) slog.Record {
+	return slog.NewRecord(someTime, slog.LevelInfo, "msg", pc)
+}
+
+type testPC struct{}
+
+func (testPC) pc() uintptr {
+	pc, _, _, _ := runtime.Caller(0)
+	return pc
+}
+
+func inlinedPC() uintptr {
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	return pcs[0]
+}
@@ -0,0 +1,201 @@
+package yall
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// StackKey is the key used by [JSON] for the stack trace.
+const StackKey = "stack"
+
+// Stack is a stack trace as a list of program counters, as returned by [runtime.Callers].
+// It is formatted as a multi-line string with a function and a file:line pair per frame,
+// and as a JSON array of objects in the shape of [slog.Source].
+type Stack []uintptr
+
+// CaptureStack returns the stack of the calling goroutine. The argument skip is the number
+// of stack frames to skip, with 0 identifying the caller of CaptureStack.
+func CaptureStack(skip int) Stack {
+	pcs := make([]uintptr, 64)
+	for {
+		n := runtime.Callers(skip+2, pcs)
+		if n < len(pcs) {
+			return Stack(pcs[:n:n])
+		}
+		pcs = make([]uintptr, len(pcs)*2)
+	}
+}
+
+// Frames returns the frames of the stack, with inlined functions expanded.
+func (s Stack) Frames() []runtime.Frame {
+	if len(s) == 0 {
+		return nil
+	}
+	var frames []runtime.Frame
+	fs := runtime.CallersFrames(s)
+	for {
+		f, more := fs.Next()
+		frames = append(frames, f)
+		if !more {
+			return frames
+		}
+	}
+}
+
+func (s Stack) String() string {
+	return string(s.appendText(nil, ""))
+}
+
+func (s Stack) MarshalJSON() ([]byte, error) {
+	b := []byte{'['}
+	for i, f := range s.Frames() {
+		if i != 0 {
+			b = append(b, ',')
+		}
+		b = append(b, `{"function":`...)
+		b = appendJSONString(b, f.Function)
+		b = append(b, `,"file":`...)
+		b = appendJSONString(b, f.File)
+		b = append(b, `,"line":`...)
+		b = strconv.AppendInt(b, int64(f.Line), 10)
+		b = append(b, '}')
+	}
+	return append(b, ']'), nil
+}
+
+// appendText appends the stack as lines of text, each starting with indent.
+func (s Stack) appendText(b []byte, indent string) []byte {
+	for i, f := range s.Frames() {
+		if i != 0 {
+			b = append(b, '\n')
+		}
+		b = append(b, indent...)
+		b = append(b, f.Function...)
+		b = append(b, '\n')
+		b = append(b, indent...)
+		b = append(b, '\t')
+		b = append(b, f.File...)
+		b = append(b, ':')
+		b = strconv.AppendInt(b, int64(f.Line), 10)
+	}
+	return b
+}
+
+type stackKey struct{}
+
+// WithStack returns a copy of ctx carrying the stack trace of the record being handled.
+// The handler does this when configured with [HandlerOptions.StackLevel].
+func WithStack(ctx context.Context, s Stack) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, stackKey{}, s)
+}
+
+// ContextStack returns the stack trace added to ctx with [WithStack], or nil.
+func ContextStack(ctx context.Context) Stack {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(stackKey{}).(Stack)
+	return s
+}
+
+// StackTracer is implemented by errors which carry a stack trace as a list of program
+// counters, see [ErrorStack].
+type StackTracer interface {
+	StackTrace() []uintptr
+}
+
+// ErrorStack returns the stack trace carried by err if it implements [StackTracer], or nil.
+// Errors with stack traces of other types, such as the ones of github.com/pkg/errors,
+// can be wrapped in a type converting their frames to program counters.
+func ErrorStack(err error) Stack {
+	if st, ok := err.(StackTracer); ok {
+		return st.StackTrace()
+	}
+	return nil
+}
+
+// StackTrace is a [Formatter] that formats stack traces and errors as an indented
+// multi-line block, intended to follow the rest of the log line.
+//
+// The block contains the stack trace captured by the handler, see [HandlerOptions.StackLevel],
+// followed by the error attributes of the record, including those in groups, which wrap
+// other errors or carry stack traces. Each such error is printed with its chain of wrapped
+// errors, as returned by [errors.Unwrap] or by an Unwrap() []error method like the one of
+// errors created with [errors.Join], and the stack traces found in the chain, see [ErrorStack].
+//
+// Each line starts with a new line character and Indent, or a tab if Indent is empty.
+// The result is empty if there is nothing to print.
+type StackTrace struct {
+	Indent string
+}
+
+func (st StackTrace) Append(b []byte, c context.Context, r slog.Record) []byte {
+	indent := orDefault(st.Indent, "\t")
+	if s := ContextStack(c); len(s) != 0 {
+		b = append(b, '\n')
+		b = append(b, indent...)
+		b = append(b, "stack:\n"...)
+		b = s.appendText(b, indent+"\t")
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		b = st.appendErrors(b, "", a, indent)
+		return true
+	})
+	return b
+}
+
+func (st StackTrace) appendErrors(b []byte, pfx string, a slog.Attr, indent string) []byte {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			pfx += a.Key + "."
+		}
+		for _, aa := range a.Value.Group() {
+			b = st.appendErrors(b, pfx, aa, indent)
+		}
+		return b
+	}
+	if a.Value.Kind() != slog.KindAny {
+		return b
+	}
+	err, ok := a.Value.Any().(error)
+	if !ok || err == nil || (unwrapErrors(err) == nil && ErrorStack(err) == nil) {
+		return b
+	}
+	return appendErrorChain(b, pfx+a.Key, err, indent)
+}
+
+// appendErrorChain appends err with the given label, its stack trace, and the errors it wraps.
+func appendErrorChain(b []byte, label string, err error, indent string) []byte {
+	b = append(b, '\n')
+	b = append(b, indent...)
+	b = append(b, label...)
+	b = append(b, ": "...)
+	b = append(b, strings.ReplaceAll(err.Error(), "\n", "\n"+indent)...)
+	if s := ErrorStack(err); len(s) != 0 {
+		b = append(b, '\n')
+		b = s.appendText(b, indent+"\t")
+	}
+	for _, e := range unwrapErrors(err) {
+		b = appendErrorChain(b, "caused by", e, indent+"\t")
+	}
+	return b
+}
+
+// unwrapErrors returns the errors wrapped by err.
+func unwrapErrors(err error) []error {
+	if u, ok := err.(interface{ Unwrap() []error }); ok {
+		return u.Unwrap()
+	}
+	if e := errors.Unwrap(err); e != nil {
+		return []error{e}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package yall_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"github.com/snake-scaly/yall"
+	"testing"
+	"time"
+)
+
+func TestHandler_StackLevel(t *testing.T) {
+	s := &testSink{enabled: true}
+	logger := slog.New(yall.NewHandlerWithOptions(s, &yall.HandlerOptions{StackLevel: slog.LevelError}))
+	logger.Warn("no stack")
+	logger.Error("stack")
+
+	assert.Nil(t, yall.ContextStack(s.calls[0].ctx))
+	frames := yall.ContextStack(s.calls[1].ctx).Frames()
+	if assert.NotEmpty(t, frames) {
+		assert.Equal(t, "github.com/snake-scaly/yall_test.TestHandler_StackLevel", frames[0].Function)
+	}
+}
+
+func TestStack_Format(t *testing.T) {
+	s := yall.Stack{testPC{}.pc()}
+	assert.Regexp(t, `^github.com/snake-scaly/yall_test.testPC.pc\n\t.*/source_test.go:107$`, s.String())
+
+	b, err := s.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Regexp(t, `^\[\{"function":"github.com/snake-scaly/yall_test.testPC.pc","file":".*/source_test.go","line":107\}\]$`, string(b))
+}
+
+func TestJSON_Stack(t *testing.T) {
+	ctx := yall.WithStack(someCtx, yall.Stack{testPC{}.pc()})
+	r := slog.NewRecord(time.Time{}, slog.LevelError, "msg", 0)
+	s := formatToString(yall.JSON{}, ctx, r)
+	assert.Regexp(t, `^\{"level":"ERROR","msg":"msg","stack":\[\{"function":"github.com/snake-scaly/yall_test.testPC.pc",.*\}\]\}$`, s)
+}
+
+func TestErrorStack(t *testing.T) {
+	pc := testPC{}.pc()
+	assert.Equal(t, yall.Stack{pc}, yall.ErrorStack(stackError{"e", yall.Stack{pc}}))
+	assert.Nil(t, yall.ErrorStack(errors.New("e")))
+}
+
+func TestStackTrace_Append(t *testing.T) {
+	inner := errors.New("inner")
+	tests := []struct {
+		name string
+		rec  slog.Record
+		want string
+	}{
+		{
+			name: "Empty",
+			rec:  rec("a", 1, "err", inner),
+			want: "",
+		},
+		{
+			name: "Wrapped",
+			rec:  rec("err", fmt.Errorf("outer: %w", inner)),
+			want: "\n\terr: outer: inner\n\t\tcaused by: inner",
+		},
+		{
+			name: "Joined",
+			rec:  rec(slog.Group("g", "err", errors.Join(inner, errors.New("other")))),
+			want: "\n\tg.err: inner\n\tother\n\t\tcaused by: inner\n\t\tcaused by: other",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := formatToString(yall.StackTrace{}, nil, tt.rec)
+			assert.Equal(t, tt.want, s)
+		})
+	}
+}
+
+func TestStackTrace_ErrorStack(t *testing.T) {
+	r := rec("err", fmt.Errorf("wrap: %w", stackError{"e", yall.Stack{testPC{}.pc()}}))
+	s := formatToString(yall.StackTrace{}, nil, r)
+	assert.Regexp(t, `^\n\terr: wrap: e\n\t\tcaused by: e\n\t\t\tgithub.com/snake-scaly/yall_test.testPC.pc\n\t\t\t\t.*/source_test.go:107$`, s)
+}
+
+func TestStackTrace_ContextStack(t *testing.T) {
+	ctx := yall.WithStack(someCtx, yall.Stack{testPC{}.pc()})
+	s := formatToString(yall.StackTrace{Indent: "  "}, ctx, rec())
+	assert.Regexp(t, `^\n  stack:\n  \tgithub.com/snake-scaly/yall_test.testPC.pc\n  \t\t.*/source_test.go:107$`, s)
+}
+
+type stackError struct {
+	msg   string
+	stack yall.Stack
+}
+
+func (e stackError) Error() string {
+	return e.msg
+}
+
+func (e stackError) StackTrace() []uintptr {
+	return e.stack
+}
@@ -0,0 +1,397 @@
+package yall
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyslogProtocol defines the message format used by a [SyslogSink].
+type SyslogProtocol int
+
+const (
+	SyslogRFC5424 = SyslogProtocol(iota) // The syslog protocol defined in RFC 5424.
+	SyslogRFC3164                        // The BSD syslog protocol described in RFC 3164.
+)
+
+// SyslogFacility is a syslog facility code.
+type SyslogFacility int
+
+const (
+	FacilityKern = SyslogFacility(iota)
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+	_
+	_
+	_
+	_
+	FacilityLocal0
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// SyslogSeverity is a syslog severity code.
+type SyslogSeverity int
+
+const (
+	SeverityEmergency = SyslogSeverity(iota)
+	SeverityAlert
+	SeverityCritical
+	SeverityError
+	SeverityWarning
+	SeverityNotice
+	SeverityInfo
+	SeverityDebug
+)
+
+// DefaultSyslogSeverities returns a level to severity map suitable for
+// [SyslogSink.Severities]. It maps [LevelFatal] to critical, errors to error,
+// warnings to warning, [LevelNotice] to notice, info to info, and lower levels to debug.
+func DefaultSyslogSeverities() map[slog.Level]SyslogSeverity {
+	return map[slog.Level]SyslogSeverity{
+		LevelFatal:      SeverityCritical,
+		slog.LevelError: SeverityError,
+		slog.LevelWarn:  SeverityWarning,
+		LevelNotice:     SeverityNotice,
+		slog.LevelInfo:  SeverityInfo,
+	}
+}
+
+// defaultSyslogSeverities is used by sinks without Severities. It must not be modified.
+var defaultSyslogSeverities = DefaultSyslogSeverities()
+
+// syslogSDID is the default SD-ID of structured data, using the enterprise number
+// reserved for documentation in RFC 5612.
+const syslogSDID = "yall@32473"
+
+var errSyslogSinkClosed = errors.New("yall: syslog sink is closed")
+
+var (
+	_ Sink   = (*SyslogSink)(nil)
+	_ Closer = (*SyslogSink)(nil)
+)
+
+// SyslogSink is a sink that sends records to a syslog daemon.
+//
+// Network and Addr are passed to [net.Dialer.Dial]. Datagram networks such as "udp" and "unixgram"
+// carry a message per datagram, stream networks such as "tcp" and "unix" frame messages
+// with octet counting as described in RFC 6587. If Network is empty, the sink connects
+// to the local daemon over a unix datagram socket at Addr, or at one of the usual paths
+// if Addr is empty too.
+//
+// The connection is established on the first Handle. If sending fails, the sink
+// reconnects and tries once more.
+type SyslogSink struct {
+	Network string
+	Addr    string
+	Level   slog.Leveler
+
+	// Format formats the MSG part. The default is the message followed by [TextAttrs].
+	Format Formatter
+
+	Protocol SyslogProtocol
+
+	// Facility is the facility of all messages. The zero value selects [FacilityUser],
+	// because processes other than the kernel are not supposed to use [FacilityKern].
+	Facility SyslogFacility
+
+	// Severities maps levels to severities. The entry with the greatest level not exceeding
+	// the record level is used, and levels below all entries are mapped to debug.
+	// The default is [DefaultSyslogSeverities].
+	Severities map[slog.Level]SyslogSeverity
+
+	// Hostname, AppName, and ProcID fill the corresponding header fields. They default
+	// to the host name, the executable name, and the process ID.
+	Hostname string
+	AppName  string
+	ProcID   string
+
+	// MsgIDKey is the key of the attr whose value is sent as MSGID with RFC 5424.
+	MsgIDKey string
+
+	// SDKeys are dot-separated keys of attrs sent as parameters of RFC 5424 structured data
+	// element SDID. SDID defaults to "yall@32473", which uses the enterprise number reserved
+	// for documentation; set it to an ID with the enterprise number of your organization.
+	SDID   string
+	SDKeys []string
+
+	// DialTimeout and WriteTimeout limit the time spent connecting and sending a message.
+	// The default for both is 10 seconds.
+	DialTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	lock   sync.Mutex
+	conn   net.Conn
+	stream bool
+	buffer []byte
+	closed bool
+	host   string
+	app    string
+	pid    string
+}
+
+func (s *SyslogSink) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= s.Level.Level()
+}
+
+func (s *SyslogSink) Handle(c context.Context, r slog.Record) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return errSyslogSinkClosed
+	}
+	if s.host == "" {
+		s.initHeader()
+	}
+
+	s.buffer = s.appendMessage(s.buffer[:0], c, r)
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if err = s.connect(); err != nil {
+				continue
+			}
+		}
+		if err = s.write(s.buffer); err == nil {
+			return nil
+		}
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// Close closes the connection. Handle fails after Close.
+func (s *SyslogSink) Close(context.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *SyslogSink) initHeader() {
+	s.host = s.Hostname
+	if s.host == "" {
+		s.host, _ = os.Hostname()
+	}
+	s.app = s.AppName
+	if s.app == "" {
+		s.app = filepath.Base(os.Args[0])
+	}
+	s.pid = s.ProcID
+	if s.pid == "" {
+		s.pid = strconv.Itoa(os.Getpid())
+	}
+}
+
+func (s *SyslogSink) connect() error {
+	d := &net.Dialer{Timeout: orDefaultDuration(s.DialTimeout, 10*time.Second)}
+	if s.Network != "" {
+		conn, err := d.Dial(s.Network, s.Addr)
+		if err != nil {
+			return err
+		}
+		_, s.stream = conn.(*net.TCPConn)
+		if uc, ok := conn.(*net.UnixConn); ok {
+			s.stream = uc.LocalAddr().Network() == "unix"
+		}
+		s.conn = conn
+		return nil
+	}
+
+	paths := []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+	if s.Addr != "" {
+		paths = []string{s.Addr}
+	}
+	var errs []error
+	for _, p := range paths {
+		conn, err := d.Dial("unixgram", p)
+		if err == nil {
+			s.conn, s.stream = conn, false
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *SyslogSink) write(msg []byte) error {
+	deadline := time.Now().Add(orDefaultDuration(s.WriteTimeout, 10*time.Second))
+	if err := s.conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+	if s.stream {
+		frame := strconv.AppendInt(make([]byte, 0, 8+len(msg)), int64(len(msg)), 10)
+		frame = append(frame, ' ')
+		msg = append(frame, msg...)
+	}
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+func (s *SyslogSink) appendMessage(b []byte, c context.Context, r slog.Record) []byte {
+	facility := s.Facility
+	if facility == FacilityKern {
+		facility = FacilityUser
+	}
+	b = append(b, '<')
+	b = strconv.AppendInt(b, int64(facility)*8+int64(syslogSeverity(s.Severities, r.Level)), 10)
+	b = append(b, '>')
+
+	if s.Protocol == SyslogRFC3164 {
+		t := r.Time
+		if t.IsZero() {
+			t = time.Now()
+		}
+		b = t.AppendFormat(b, time.Stamp)
+		b = append(b, ' ')
+		b = appendSyslogField(b, s.host, 255)
+		b = append(b, ' ')
+		b = appendSyslogField(b, s.app, 32)
+		b = append(b, '[')
+		b = appendSyslogField(b, s.pid, 128)
+		b = append(b, "]: "...)
+		return s.appendMsg(b, c, r)
+	}
+
+	b = append(b, "1 "...)
+	if r.Time.IsZero() {
+		b = append(b, '-')
+	} else {
+		b = r.Time.AppendFormat(b, "2006-01-02T15:04:05.000000Z07:00")
+	}
+	b = append(b, ' ')
+	b = appendSyslogField(b, s.host, 255)
+	b = append(b, ' ')
+	b = appendSyslogField(b, s.app, 48)
+	b = append(b, ' ')
+	b = appendSyslogField(b, s.pid, 128)
+	b = append(b, ' ')
+	msgID := ""
+	if v, ok := recordAttr(r, s.MsgIDKey); ok && s.MsgIDKey != "" {
+		msgID = v.String()
+	}
+	b = appendSyslogField(b, msgID, 32)
+	b = append(b, ' ')
+	b = s.appendStructuredData(b, r)
+
+	mark := len(b)
+	b = append(b, ' ')
+	if b = s.appendMsg(b, c, r); len(b) == mark+1 {
+		b = b[:mark]
+	}
+	return b
+}
+
+func (s *SyslogSink) appendMsg(b []byte, c context.Context, r slog.Record) []byte {
+	if s.Format == nil {
+		b = append(b, r.Message...)
+		return TextAttrs{Quote: QuoteSmart}.Append(b, c, r)
+	}
+	return s.Format.Append(b, c, r)
+}
+
+func (s *SyslogSink) appendStructuredData(b []byte, r slog.Record) []byte {
+	start := len(b)
+	for _, key := range s.SDKeys {
+		v, found := recordAttr(r, key)
+		if !found {
+			continue
+		}
+		if len(b) == start {
+			b = append(b, '[')
+			b = appendSyslogField(b, orDefault(s.SDID, syslogSDID), 32)
+		}
+		b = append(b, ' ')
+		b = appendSyslogName(b, key)
+		b = append(b, `="`...)
+		b = appendSDValue(b, v.String())
+		b = append(b, '"')
+	}
+	if len(b) == start {
+		return append(b, '-')
+	}
+	return append(b, ']')
+}
+
+// syslogSeverity returns the severity of l according to the level to severity map m,
+// see [SyslogSink.Severities].
+func syslogSeverity(m map[slog.Level]SyslogSeverity, l slog.Level) SyslogSeverity {
+	if m == nil {
+		m = defaultSyslogSeverities
+	}
+	if severity, found := nearestLevel(m, l); found {
+		return severity
+	}
+	return SeverityDebug
+}
+
+// appendSyslogField appends a header field as printable US-ASCII limited to n bytes,
+// or "-" if the field is empty.
+func appendSyslogField(b []byte, f string, n int) []byte {
+	if f == "" {
+		return append(b, '-')
+	}
+	for i := 0; i < len(f) && i < n; i++ {
+		if c := f[i]; c > ' ' && c < 0x7f {
+			b = append(b, c)
+		} else {
+			b = append(b, '_')
+		}
+	}
+	return b
+}
+
+// appendSyslogName appends a structured data parameter name,
+// replacing the characters it must not contain.
+func appendSyslogName(b []byte, name string) []byte {
+	start := len(b)
+	b = appendSyslogField(b, name, 32)
+	for i := start; i < len(b); i++ {
+		if c := b[i]; c == '=' || c == ']' || c == '"' {
+			b[i] = '_'
+		}
+	}
+	return b
+}
+
+func appendSDValue(b []byte, v string) []byte {
+	if !strings.ContainsAny(v, `"\]`) {
+		return append(b, v...)
+	}
+	for i := 0; i < len(v); i++ {
+		if c := v[i]; c == '"' || c == '\\' || c == ']' {
+			b = append(b, '\\')
+		}
+		b = append(b, v[i])
+	}
+	return b
+}
@@ -0,0 +1,283 @@
+package yall_test
+
+import (
+	"bufio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"github.com/snake-scaly/yall"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogSink_RFC5424(t *testing.T) {
+	tests := []struct {
+		name string
+		sink *yall.SyslogSink
+		rec  slog.Record
+		want string
+	}{
+		{
+			name: "Default",
+			sink: &yall.SyslogSink{},
+			rec:  rec("a", 1),
+			want: "<14>1 2020-11-22T12:34:56.000000Z host app 42 - - msg a=1",
+		},
+		{
+			name: "Facility",
+			sink: &yall.SyslogSink{Facility: yall.FacilityLocal3},
+			rec:  rec(),
+			want: "<158>1 2020-11-22T12:34:56.000000Z host app 42 - - msg",
+		},
+		{
+			name: "MsgID",
+			sink: &yall.SyslogSink{MsgIDKey: "req.kind"},
+			rec:  rec(slog.Group("req", "kind", "login")),
+			want: "<14>1 2020-11-22T12:34:56.000000Z host app 42 login - msg req.kind=login",
+		},
+		{
+			name: "StructuredData",
+			sink: &yall.SyslogSink{SDKeys: []string{"user", "missing", "req.id"}},
+			rec:  rec("user", `a"b]c\d`, slog.Group("req", "id", 7)),
+			want: `<14>1 2020-11-22T12:34:56.000000Z host app 42 - [yall@32473 user="a\"b\]c\\d" req.id="7"] msg user=a"b]c\d req.id=7`,
+		},
+		{
+			name: "SDID",
+			sink: &yall.SyslogSink{SDID: "app@12345", SDKeys: []string{"user"}},
+			rec:  rec("user", "bob"),
+			want: `<14>1 2020-11-22T12:34:56.000000Z host app 42 - [app@12345 user="bob"] msg user=bob`,
+		},
+		{
+			name: "Format",
+			sink: &yall.SyslogSink{Format: yall.Message{}},
+			rec:  rec("a", 1),
+			want: "<14>1 2020-11-22T12:34:56.000000Z host app 42 - - msg",
+		},
+		{
+			name: "EmptyMessage",
+			sink: &yall.SyslogSink{Format: yall.Attr{Key: "x"}},
+			rec:  rec(),
+			want: "<14>1 2020-11-22T12:34:56.000000Z host app 42 - -",
+		},
+		{
+			name: "ZeroTime",
+			sink: &yall.SyslogSink{},
+			rec:  slog.NewRecord(time.Time{}, slog.LevelWarn, "msg", 0),
+			want: "<12>1 - host app 42 - - msg",
+		},
+		{
+			name: "Protocol3164",
+			sink: &yall.SyslogSink{Protocol: yall.SyslogRFC3164, SDKeys: []string{"a"}},
+			rec:  rec("a", 1),
+			want: "<14>Nov 22 12:34:56 host app[42]: msg a=1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			require.Nil(t, err)
+			defer conn.Close()
+
+			s := tt.sink
+			s.Network = "udp"
+			s.Addr = conn.LocalAddr().String()
+			s.Hostname = "host"
+			s.AppName = "app"
+			s.ProcID = "42"
+			assert.Nil(t, s.Handle(someCtx, tt.rec))
+			assert.Nil(t, s.Close(someCtx))
+
+			assert.Equal(t, tt.want, readDatagram(t, conn))
+		})
+	}
+}
+
+func TestSyslogSink_Severities(t *testing.T) {
+	tests := []struct {
+		name       string
+		severities map[slog.Level]yall.SyslogSeverity
+		level      slog.Level
+		want       string
+	}{
+		{
+			name:  "Trace",
+			level: yall.LevelTrace,
+			want:  "<15>",
+		},
+		{
+			name:  "Debug",
+			level: slog.LevelDebug,
+			want:  "<15>",
+		},
+		{
+			name:  "Info",
+			level: slog.LevelInfo,
+			want:  "<14>",
+		},
+		{
+			name:  "Notice",
+			level: yall.LevelNotice,
+			want:  "<13>",
+		},
+		{
+			name:  "Warn",
+			level: slog.LevelWarn,
+			want:  "<12>",
+		},
+		{
+			name:  "Error",
+			level: slog.LevelError,
+			want:  "<11>",
+		},
+		{
+			name:  "ErrorPlus2",
+			level: slog.LevelError + 2,
+			want:  "<11>",
+		},
+		{
+			name:  "Fatal",
+			level: yall.LevelFatal,
+			want:  "<10>",
+		},
+		{
+			name:       "Custom",
+			severities: map[slog.Level]yall.SyslogSeverity{slog.LevelError: yall.SeverityEmergency},
+			level:      slog.LevelError,
+			want:       "<8>",
+		},
+		{
+			name:       "CustomBelow",
+			severities: map[slog.Level]yall.SyslogSeverity{slog.LevelError: yall.SeverityEmergency},
+			level:      slog.LevelWarn,
+			want:       "<15>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			require.Nil(t, err)
+			defer conn.Close()
+
+			s := &yall.SyslogSink{Network: "udp", Addr: conn.LocalAddr().String(), Severities: tt.severities}
+			assert.Nil(t, s.Handle(someCtx, slog.NewRecord(someTime, tt.level, "msg", 0)))
+			assert.Nil(t, s.Close(someCtx))
+
+			assert.True(t, strings.HasPrefix(readDatagram(t, conn), tt.want+"1 "))
+		})
+	}
+}
+
+func TestSyslogSink_TCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer l.Close()
+
+	s := &yall.SyslogSink{Network: "tcp", Addr: l.Addr().String(), Hostname: "h", AppName: "a", ProcID: "1"}
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "one")))
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "two\nlines")))
+
+	conn, err := l.Accept()
+	require.Nil(t, err)
+	defer conn.Close()
+	require.Nil(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	r := bufio.NewReader(conn)
+	assert.Equal(t, "<14>1 2020-11-22T12:34:56.000000Z h a 1 - - one", readOctetCounted(t, r))
+	assert.Equal(t, "<14>1 2020-11-22T12:34:56.000000Z h a 1 - - two\nlines", readOctetCounted(t, r))
+
+	assert.Nil(t, s.Close(someCtx))
+	assert.NotNil(t, s.Handle(someCtx, msgRec(someTime, "three")))
+}
+
+func TestSyslogSink_Reconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer l.Close()
+
+	s := &yall.SyslogSink{Network: "tcp", Addr: l.Addr().String(), Hostname: "h", AppName: "a", ProcID: "1"}
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "one")))
+	conn, err := l.Accept()
+	require.Nil(t, err)
+	require.Nil(t, conn.Close())
+
+	// The first writes after the peer is gone may succeed, but the sink must reconnect eventually.
+	var conn2 net.Conn
+	accepted := make(chan struct{})
+	go func() {
+		conn2, _ = l.Accept()
+		close(accepted)
+	}()
+	deadline := time.After(5 * time.Second)
+	for done := false; !done; {
+		_ = s.Handle(someCtx, msgRec(someTime, "two"))
+		select {
+		case <-accepted:
+			done = true
+		case <-deadline:
+			t.Fatal("sink did not reconnect")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	require.NotNil(t, conn2)
+	defer conn2.Close()
+	assert.Nil(t, s.Close(someCtx))
+}
+
+func TestSyslogSink_Unixgram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	conn, err := net.ListenPacket("unixgram", path)
+	require.Nil(t, err)
+	defer conn.Close()
+
+	s := &yall.SyslogSink{Addr: path, Protocol: yall.SyslogRFC3164, Hostname: "h", AppName: "a", ProcID: "1"}
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "one")))
+	assert.Nil(t, s.Close(someCtx))
+
+	assert.Equal(t, "<14>Nov 22 12:34:56 h a[1]: one", readDatagram(t, conn))
+}
+
+func TestSyslogSink_Defaults(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer conn.Close()
+
+	s := &yall.SyslogSink{Network: "udp", Addr: conn.LocalAddr().String()}
+	assert.Nil(t, s.Handle(someCtx, msgRec(someTime, "one")))
+	assert.Nil(t, s.Close(someCtx))
+
+	fields := strings.Split(readDatagram(t, conn), " ")
+	require.Len(t, fields, 8)
+	assert.NotEqual(t, "-", fields[2])
+	assert.NotEqual(t, "-", fields[3])
+	_, err = strconv.Atoi(fields[4])
+	assert.Nil(t, err)
+}
+
+func TestSyslogSink_Enabled(t *testing.T) {
+	s := &yall.SyslogSink{Level: slog.LevelWarn}
+	assert.False(t, s.Enabled(someCtx, slog.LevelInfo))
+	assert.True(t, s.Enabled(someCtx, slog.LevelWarn))
+}
+
+func readDatagram(t *testing.T, conn net.PacketConn) string {
+	require.Nil(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 4096)
+	n, _, err := conn.ReadFrom(buf)
+	require.Nil(t, err)
+	return string(buf[:n])
+}
+
+func readOctetCounted(t *testing.T, r *bufio.Reader) string {
+	length, err := r.ReadString(' ')
+	require.Nil(t, err)
+	n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+	require.Nil(t, err)
+	buf := make([]byte, n)
+	_, err = io.ReadFull(r, buf)
+	require.Nil(t, err)
+	return string(buf)
+}
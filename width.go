@@ -0,0 +1,230 @@
+package yall
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Align defines how [Pad] places text within its width.
+type Align int
+
+const (
+	AlignLeft   = Align(iota) // Add padding on the right.
+	AlignRight                // Add padding on the left.
+	AlignCenter               // Split padding between both sides, with the extra space on the right.
+)
+
+// Pad is a [Formatter] that pads the output of Inner with spaces to at least Width
+// display cells. Unlike width modifiers in [Layout] formats, which count bytes, Pad counts
+// the cells occupied in a terminal: East Asian wide characters take two cells, combining
+// marks and ANSI escape sequences take none.
+type Pad struct {
+	Inner Formatter
+	Width int
+	Align Align
+}
+
+func (p Pad) Append(b []byte, c context.Context, r slog.Record) []byte {
+	start := len(b)
+	b = p.Inner.Append(b, c, r)
+	n := p.Width - displayWidth(b[start:])
+	if n <= 0 {
+		return b
+	}
+	switch p.Align {
+	case AlignRight:
+		return insertSpaces(b, start, n)
+	case AlignCenter:
+		b = insertSpaces(b, start, n/2)
+		return appendSpaces(b, n-n/2)
+	}
+	return appendSpaces(b, n)
+}
+
+// Truncate is a [Formatter] that shortens the output of Inner to at most Width display
+// cells, counted like in [Pad]. Truncated output ends with Marker, for example "…",
+// which is included in Width and cut to Width if it is wider. If KeepEnd is true, the beginning of the output is removed
+// instead, and the output starts with Marker. This suits file paths.
+//
+// If the remaining part of the output contains ANSI escape sequences, a reset sequence
+// is added so that colors don't leak past the truncated text.
+type Truncate struct {
+	Inner   Formatter
+	Width   int
+	Marker  string
+	KeepEnd bool
+}
+
+func (t Truncate) Append(b []byte, c context.Context, r slog.Record) []byte {
+	start := len(b)
+	b = t.Inner.Append(b, c, r)
+	s := b[start:]
+	if displayWidth(s) <= t.Width {
+		return b
+	}
+	marker := []byte(t.Marker)
+	marker = marker[:widthPrefix(marker, max(t.Width, 0))]
+	avail := t.Width - displayWidth(marker)
+
+	if !t.KeepEnd {
+		n := widthPrefix(s, avail)
+		b = b[:start+n]
+		if bytes.IndexByte(b[start:], '\x1b') >= 0 {
+			b = appendColorEnd(b)
+		}
+		return append(b, marker...)
+	}
+
+	tmp := bufferPool.Get().([]byte)[:0]
+	defer func() {
+		bufferPool.Put(tmp)
+	}()
+	tmp = append(tmp, s[widthSuffix(s, avail):]...)
+	b = append(b[:start], marker...)
+	b = append(b, tmp...)
+	if bytes.IndexByte(tmp, '\x1b') >= 0 {
+		b = appendColorEnd(b)
+	}
+	return b
+}
+
+// displayWidth returns the number of terminal cells occupied by s.
+func displayWidth(s []byte) (w int) {
+	for len(s) != 0 {
+		n, cw := nextCell(s)
+		w += cw
+		s = s[n:]
+	}
+	return
+}
+
+// widthPrefix returns the length in bytes of the longest prefix of s
+// that fits into width cells.
+func widthPrefix(s []byte, width int) int {
+	i := 0
+	for i < len(s) {
+		n, cw := nextCell(s[i:])
+		if cw > width {
+			break
+		}
+		width -= cw
+		i += n
+	}
+	return i
+}
+
+// widthSuffix returns the start of the longest suffix of s that fits into width cells.
+func widthSuffix(s []byte, width int) int {
+	// Cell boundaries can only be found going forward because of escape sequences.
+	var ends []int
+	var widths []int
+	for i := 0; i < len(s); {
+		n, cw := nextCell(s[i:])
+		i += n
+		ends = append(ends, i)
+		widths = append(widths, cw)
+	}
+	start := len(s)
+	for j := len(ends) - 1; j >= 0; j-- {
+		if widths[j] > width {
+			break
+		}
+		width -= widths[j]
+		if j == 0 {
+			start = 0
+		} else {
+			start = ends[j-1]
+		}
+	}
+	return start
+}
+
+// nextCell returns the size in bytes and the width in cells of the first rune
+// or ANSI escape sequence in s.
+func nextCell(s []byte) (size, width int) {
+	if s[0] == '\x1b' && len(s) > 1 {
+		if s[1] != '[' {
+			return 2, 0
+		}
+		// CSI: parameter and intermediate bytes followed by a final byte
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1, 0
+			}
+		}
+		return len(s), 0
+	}
+	r, size := utf8.DecodeRune(s)
+	return size, runeWidth(r)
+}
+
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20 || (r >= 0x7f && r < 0xa0):
+		return 0
+	case r < 0x300:
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+func isWide(r rune) bool {
+	i := sort.Search(len(wideRanges), func(i int) bool {
+		return wideRanges[i][1] >= r
+	})
+	return i < len(wideRanges) && wideRanges[i][0] <= r
+}
+
+// wideRanges lists East Asian Wide and Fullwidth characters, including emoji
+// presented as wide.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115f}, {0x231a, 0x231b}, {0x2329, 0x232a}, {0x23e9, 0x23ec},
+	{0x23f0, 0x23f0}, {0x23f3, 0x23f3}, {0x25fd, 0x25fe}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267f, 0x267f}, {0x2693, 0x2693}, {0x26a1, 0x26a1},
+	{0x26aa, 0x26ab}, {0x26bd, 0x26be}, {0x26c4, 0x26c5}, {0x26ce, 0x26ce},
+	{0x26d4, 0x26d4}, {0x26ea, 0x26ea}, {0x26f2, 0x26f3}, {0x26f5, 0x26f5},
+	{0x26fa, 0x26fa}, {0x26fd, 0x26fd}, {0x2705, 0x2705}, {0x270a, 0x270b},
+	{0x2728, 0x2728}, {0x274c, 0x274c}, {0x274e, 0x274e}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27b0, 0x27b0}, {0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c}, {0x2b50, 0x2b50}, {0x2b55, 0x2b55}, {0x2e80, 0x303e},
+	{0x3041, 0x33ff}, {0x3400, 0x4dbf}, {0x4e00, 0x9fff}, {0xa000, 0xa4cf},
+	{0xa960, 0xa97f}, {0xac00, 0xd7a3}, {0xf900, 0xfaff}, {0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f}, {0xff00, 0xff60}, {0xffe0, 0xffe6}, {0x16fe0, 0x16fe4},
+	{0x17000, 0x18aff}, {0x1b000, 0x1b2ff}, {0x1f004, 0x1f004}, {0x1f0cf, 0x1f0cf},
+	{0x1f18e, 0x1f18e}, {0x1f191, 0x1f19a}, {0x1f200, 0x1f202}, {0x1f210, 0x1f23b},
+	{0x1f240, 0x1f248}, {0x1f250, 0x1f251}, {0x1f260, 0x1f265}, {0x1f300, 0x1f320},
+	{0x1f32d, 0x1f335}, {0x1f337, 0x1f37c}, {0x1f37e, 0x1f393}, {0x1f3a0, 0x1f3ca},
+	{0x1f3cf, 0x1f3d3}, {0x1f3e0, 0x1f3f0}, {0x1f3f4, 0x1f3f4}, {0x1f3f8, 0x1f43e},
+	{0x1f440, 0x1f440}, {0x1f442, 0x1f4fc}, {0x1f4ff, 0x1f53d}, {0x1f54b, 0x1f54e},
+	{0x1f550, 0x1f567}, {0x1f57a, 0x1f57a}, {0x1f595, 0x1f596}, {0x1f5a4, 0x1f5a4},
+	{0x1f5fb, 0x1f64f}, {0x1f680, 0x1f6c5}, {0x1f6cc, 0x1f6cc}, {0x1f6d0, 0x1f6d2},
+	{0x1f6d5, 0x1f6d7}, {0x1f6dc, 0x1f6df}, {0x1f6eb, 0x1f6ec}, {0x1f6f4, 0x1f6fc},
+	{0x1f7e0, 0x1f7eb}, {0x1f7f0, 0x1f7f0}, {0x1f90c, 0x1f93a}, {0x1f93c, 0x1f945},
+	{0x1f947, 0x1f9ff}, {0x1fa70, 0x1faff}, {0x20000, 0x2fffd}, {0x30000, 0x3fffd},
+}
+
+func appendSpaces(b []byte, n int) []byte {
+	for ; n > 0; n-- {
+		b = append(b, ' ')
+	}
+	return b
+}
+
+// insertSpaces inserts n spaces into b at index i.
+func insertSpaces(b []byte, i, n int) []byte {
+	end := len(b)
+	b = appendSpaces(b, n)
+	copy(b[i+n:], b[i:end])
+	for j := i; j < i+n; j++ {
+		b[j] = ' '
+	}
+	return b
+}
@@ -0,0 +1,165 @@
+package yall_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/snake-scaly/yall"
+	"testing"
+)
+
+func TestPad_Append(t *testing.T) {
+	tests := []struct {
+		name  string
+		inner string
+		width int
+		align yall.Align
+		want  string
+	}{
+		{
+			name:  "Left",
+			inner: "ab",
+			width: 4,
+			want:  "ab  ",
+		},
+		{
+			name:  "Right",
+			inner: "ab",
+			width: 4,
+			align: yall.AlignRight,
+			want:  "  ab",
+		},
+		{
+			name:  "Center",
+			inner: "ab",
+			width: 5,
+			align: yall.AlignCenter,
+			want:  " ab  ",
+		},
+		{
+			name:  "TooLong",
+			inner: "abcdef",
+			width: 4,
+			want:  "abcdef",
+		},
+		{
+			name:  "NonASCII",
+			inner: "héllo",
+			width: 6,
+			align: yall.AlignRight,
+			want:  " héllo",
+		},
+		{
+			name:  "Wide",
+			inner: "日本",
+			width: 6,
+			want:  "日本  ",
+		},
+		{
+			name:  "Combining",
+			inner: "e\u0301",
+			width: 3,
+			want:  "e\u0301  ",
+		},
+		{
+			name:  "ANSI",
+			inner: "\x1b[31mab\x1b[0m",
+			width: 3,
+			align: yall.AlignRight,
+			want:  " \x1b[31mab\x1b[0m",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := yall.Pad{Inner: testFormatter{tt.inner}, Width: tt.width, Align: tt.align}
+			assert.Equal(t, "<"+tt.want, formatToString(yall.Layout{Format: "<%s", Args: []yall.Formatter{p}}, nil, rec()))
+		})
+	}
+}
+
+func TestTruncate_Append(t *testing.T) {
+	tests := []struct {
+		name    string
+		inner   string
+		width   int
+		marker  string
+		keepEnd bool
+		want    string
+	}{
+		{
+			name:  "Short",
+			inner: "abc",
+			width: 3,
+			want:  "abc",
+		},
+		{
+			name:  "NoMarker",
+			inner: "abcdef",
+			width: 3,
+			want:  "abc",
+		},
+		{
+			name:   "Marker",
+			inner:  "abcdef",
+			width:  4,
+			marker: "…",
+			want:   "abc…",
+		},
+		{
+			name:    "KeepEnd",
+			inner:   "/a/b/file.go",
+			width:   8,
+			marker:  "...",
+			keepEnd: true,
+			want:    "...le.go",
+		},
+		{
+			name:   "Wide",
+			inner:  "日本語",
+			width:  5,
+			marker: "~",
+			want:   "日本~",
+		},
+		{
+			name:   "WideBoundary",
+			inner:  "日本語",
+			width:  4,
+			marker: "~",
+			want:   "日~",
+		},
+		{
+			name:    "WideKeepEnd",
+			inner:   "日本語",
+			width:   4,
+			keepEnd: true,
+			want:    "本語",
+		},
+		{
+			name:  "ANSI",
+			inner: "\x1b[31mabcdef\x1b[0m",
+			width: 2,
+			want:  "\x1b[31mab\x1b[0m",
+		},
+		{
+			name:   "MarkerTooWide",
+			inner:  "abcdef",
+			width:  1,
+			marker: "...",
+			want:   ".",
+		},
+		{
+			name:    "MarkerTooWideKeepEnd",
+			inner:   "abcdef",
+			width:   2,
+			marker:  "...",
+			keepEnd: true,
+			want:    "..",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := yall.Truncate{Inner: testFormatter{tt.inner}, Width: tt.width, Marker: tt.marker, KeepEnd: tt.keepEnd}
+			assert.Equal(t, "<"+tt.want, formatToString(yall.Layout{Format: "<%s", Args: []yall.Formatter{tr}}, nil, rec()))
+		})
+	}
+}
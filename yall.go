@@ -31,11 +31,21 @@ flexible way.
 YALL provides a set of built-in formatters:
 
   - [Time] formats [slog.Record.Time] according to the specified format.
-  - [Level] formats [slog.Record.Level] using [slog.Level.String].
-  - [Source] formats [slog.Record.PC] in long or short format.
+  - [Level] formats [slog.Record.Level] using [slog.Level.String] or custom
+    names, casing, and abbreviations defined by [LevelNames].
+  - [Source] formats [slog.Record.PC] as a full, short, relative, or trimmed path,
+    optionally with the function name.
   - [Message] formats [slog.Record.Message] with optional quoting.
   - [TextAttrs] formats [slog.Record.Attrs] in key=value format with optional value quoting.
+  - [Attr] formats the value of a single attribute, addressed by a dot-separated key.
+  - [Logfmt] formats [slog.Record.Attrs] as strictly compliant logfmt.
+  - [JSON] formats the whole record as a JSON object exactly like [slog.JSONHandler] does.
+  - [JSONAttrs] formats [slog.Record.Attrs] as a JSON object or a list of object members.
+  - [ContextValue] formats a value stored in the [context.Context].
+  - [StackTrace] formats stack traces and error chains as an indented multi-line block.
   - [Layout] composes other formatters in a manner of [fmt.Sprintf].
+  - [Colorize] wraps the output of another formatter in ANSI colors, optionally chosen by level.
+  - [Pad] and [Truncate] align and shorten the output of another formatter by display width.
   - [Conditional] is similar to [Layout] for one argument which only produces output
     if the inner formatter result is non-empty.
 
@@ -68,10 +78,24 @@ Here's another, simpler example, but this time the level is formatted to always
 		},
 	}
 
+The same formatters can be built from a pattern string, e.g. loaded from a config file,
+using [ParsePattern]. The last example is equivalent to
+
+	l, err := yall.ParsePattern("[%5level] %msg%cond(:%attrs{smart})")
+
+Custom formatters can be made available in patterns with [RegisterPattern].
+
 The [DefaultFormat] function creates a formatter to produce logs that look like
 
 	2020-11-22 12:34:56 INFO Long message foo=bar baz="quote me"
 
+The [ConsoleFormat] function creates a colored, human-friendly [Layout] for local
+development, where groups and multi-line values are indented on separate lines:
+
+	12:34:56.789 INF Long message                             foo=bar
+	    request:
+	        id=42
+
 # Sink
 
 [Sink] is responsible for delivering log records to the destination, be it console,
@@ -86,6 +110,19 @@ YALL provides the following sinks:
   - [FanOutSink] broadcasts log records to any number of other sinks. The list of
     target sinks can be modified at run time.
   - [WriterSink] writes records formatted by any [Formatter] to any [io.Writer].
+  - [FileSink] writes formatted records to a file rotated by size and time.
+  - [AsyncSink] queues records and delivers them to another sink on a background goroutine.
+  - [FilterSink] passes to another sink only the records within a level range accepted by a [Predicate].
+  - [SyslogSink] sends records to a local or remote syslog daemon.
+  - [JournaldSink] sends records to systemd-journald as structured entries.
+  - [NetSink] streams formatted records over TCP or a unix socket and reconnects on failures.
+  - [HTTPSink] posts batches of formatted records to an HTTP endpoint, retrying on failures.
+
+Sinks which buffer records or hold resources implement the optional [Flusher] and [Closer]
+interfaces. [FanOutSink] propagates them to its sinks, and the [Flush] and [Close] functions
+accept the handler returned by [NewHandler], so the whole tree of sinks can be shut down with
+
+	err := yall.Close(ctx, logger.Handler())
 
 # Handler
 
@@ -96,5 +133,21 @@ records to a [Sink] which typically will be a [FanOutSink], but can also be
 a [WriterSink] or even one of the existing slog handlers like [slog.TextHandler].
 
 Use the [NewHandler] function to create an instance of the handler.
+The handler with a [WriterSink] using the [JSON] formatter, or a key=value layout
+based on [TextAttrs], passes the testing/slogtest conformance suite.
+
+[NewHandlerWithOptions] additionally accepts a [HandlerOptions].ReplaceAttr function which rewrites
+or removes attributes with the same semantics as in [slog.HandlerOptions], so that every
+formatter sees the same rewritten record. For example, it can redact secrets or replace
+the level. Set [HandlerOptions.SlogOrder] to order attributes like the standard slog
+handlers do, with the attributes added by [slog.Logger.With] first.
+
+Attributes attached to a context with [WithContextAttrs], such as request or trace IDs,
+are added by the handler to every record logged with that context, e.g. using
+[slog.Logger.InfoContext]. [HandlerOptions.ContextAttrs] replaces the function
+extracting the attributes from the context.
+
+Set [HandlerOptions.StackLevel] to capture the stack trace of records at or above
+a level, e.g. errors. The stack trace is formatted by [StackTrace] and [JSON].
 */
 package yall
@@ -66,3 +66,22 @@ func Example() {
 	// [ INFO] Just a message
 	// [ERROR] Message with args: inner.answer=42 outer="exampli gratia"
 }
+
+func ExampleNewHandlerWithOptions() {
+	// Order attributes like slog.TextHandler does to make migration easier.
+	sink := yall.WriterSink{
+		Writer: os.Stdout,
+		Level:  slog.LevelInfo,
+		Format: &yall.Layout{
+			Format: "level=%s msg=%s%s",
+			Args:   []yall.Formatter{yall.Level{}, yall.Message{Quote: yall.QuoteSmart}, yall.TextAttrs{Quote: yall.QuoteSmart}},
+		},
+	}
+	handler := yall.NewHandlerWithOptions(&sink, &yall.HandlerOptions{SlogOrder: true})
+
+	logger := slog.New(handler).With("outer", "exampli gratia").WithGroup("inner")
+	logger.Error("Message with args", "answer", 42)
+
+	// Output:
+	// level=ERROR msg="Message with args" outer="exampli gratia" inner.answer=42
+}